@@ -2,26 +2,84 @@ package audio
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mutable"
 	"pipelined.dev/signal"
 )
 
+// ErrPeakAboveFullScale is returned when asset is normalized to the peak
+// level above 0 dBFS.
+var ErrPeakAboveFullScale = errors.New("peak above full scale")
+
+// ErrChannelGains is returned when number of channel gains doesn't match
+// the number of asset channels.
+var ErrChannelGains = errors.New("gains don't match channels")
+
+// ErrInvalidRange is returned when asset is trimmed to the range beyond
+// its signal.
+var ErrInvalidRange = errors.New("invalid asset range")
+
+// ErrNilAsset is returned when nil asset is passed.
+var ErrNilAsset = errors.New("asset is nil")
+
+// ErrEmptyAsset is returned when asset without signal is crossfaded.
+var ErrEmptyAsset = errors.New("asset has no signal")
+
+// ErrInvalidCrossfade is returned when crossfade length is negative.
+var ErrInvalidCrossfade = errors.New("crossfade length must be non-negative")
+
 // Asset is a sink which uses a regular buffer as underlying storage. It
 // can be used to slice signal data and use it as processing input. It's
 // possible to use an arbitrary signal type as a buffer. Float64 is used by
 // default.
 type Asset struct {
 	signal.Signal
+	// Complete is set by the sink when its line ended without
+	// cancellation. If the pipe is cancelled, e.g. by error in another
+	// line, the asset keeps the partial capture and Complete is false.
+	Complete bool
+	// Dither is applied when floating signal is converted into signed
+	// or unsigned asset buffer. Default is DitherNone, samples are
+	// truncated.
+	Dither     DitherType
 	sampleRate signal.Frequency
 }
 
+// NewAssetFromFloat64 returns a new asset with a copy of interleaved
+// samples. It panics if length of the slice is not divisible by the
+// number of channels.
+func NewAssetFromFloat64(sr signal.Frequency, channels int, interleaved []float64) *Asset {
+	if channels <= 0 || len(interleaved)%channels != 0 {
+		panic(fmt.Sprintf("%d samples don't fit %d channels", len(interleaved), channels))
+	}
+	length := len(interleaved) / channels
+	floats := signal.Allocator{
+		Channels: channels,
+		Length:   length,
+		Capacity: length,
+	}.Float64()
+	signal.WriteFloat64(interleaved, floats)
+	return &Asset{
+		Signal:     floats,
+		sampleRate: sr,
+	}
+}
+
 // SampleRate returns a sample rate of the asset.
 func (a *Asset) SampleRate() signal.Frequency {
 	return a.sampleRate
 }
 
+// SetSampleRate sets a sample rate of the asset. It allows to use assets
+// with signals constructed without sink. Sink overrides the sample rate.
+func (a *Asset) SetSampleRate(sr signal.Frequency) {
+	a.sampleRate = sr
+}
+
 // Sink uses signal.Floating buffer to store signal data.
 func (a *Asset) Sink() (result pipe.SinkAllocatorFunc) {
 	switch a.Signal.(type) {
@@ -38,14 +96,16 @@ func (a *Asset) Sink() (result pipe.SinkAllocatorFunc) {
 func (a *Asset) sinkFloating() pipe.SinkAllocatorFunc {
 	return func(m mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
 		a.sampleRate = props.SampleRate
+		a.Complete = false
 		data := floatingAsset(a.Signal, props.Channels, bufferSize)
 		return pipe.Sink{
 			SinkFunc: func(in signal.Floating) error {
 				data.Append(in)
 				return nil
 			},
-			FlushFunc: func(context.Context) error {
+			FlushFunc: func(ctx context.Context) error {
 				a.Signal = data
+				a.Complete = ctx.Err() == nil
 				return nil
 			},
 		}, nil
@@ -66,6 +126,7 @@ func floatingAsset(s signal.Signal, channels, bufferSize int) signal.Floating {
 func (a *Asset) sinkSigned() pipe.SinkAllocatorFunc {
 	return func(m mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
 		a.sampleRate = props.SampleRate
+		a.Complete = false
 		data := a.Signal.(signal.Signed)
 		// increment buffer is used only to grow the capacity of the data slice
 		inc := signal.Allocator{
@@ -77,11 +138,12 @@ func (a *Asset) sinkSigned() pipe.SinkAllocatorFunc {
 		return pipe.Sink{
 			SinkFunc: func(in signal.Floating) error {
 				data.Append(inc)
-				pos += signal.FloatingAsSigned(in, data.Slice(pos, pos+bufferSize))
+				pos += floatingAsSigned(in, data.Slice(pos, pos+bufferSize), a.Dither)
 				return nil
 			},
-			FlushFunc: func(context.Context) error {
+			FlushFunc: func(ctx context.Context) error {
 				a.Signal = data
+				a.Complete = ctx.Err() == nil
 				return nil
 			},
 		}, nil
@@ -91,6 +153,7 @@ func (a *Asset) sinkSigned() pipe.SinkAllocatorFunc {
 func (a *Asset) sinkUnsigned() pipe.SinkAllocatorFunc {
 	return func(m mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
 		a.sampleRate = props.SampleRate
+		a.Complete = false
 		data := a.Signal.(signal.Unsigned)
 		// increment buffer is used only to grow the capacity of the data slice
 		inc := signal.Allocator{
@@ -102,13 +165,414 @@ func (a *Asset) sinkUnsigned() pipe.SinkAllocatorFunc {
 		return pipe.Sink{
 			SinkFunc: func(in signal.Floating) error {
 				data.Append(inc)
-				pos += signal.FloatingAsUnsigned(in, data.Slice(pos, pos+bufferSize))
+				pos += floatingAsUnsigned(in, data.Slice(pos, pos+bufferSize), a.Dither)
 				return nil
 			},
-			FlushFunc: func(context.Context) error {
+			FlushFunc: func(ctx context.Context) error {
 				a.Signal = data
+				a.Complete = ctx.Err() == nil
 				return nil
 			},
 		}, nil
 	}
 }
+
+// floatingAsSigned converts floating samples into signed fixed-point.
+// Values beyond [-1, 1] are clipped to the range of the bit depth. It
+// returns the number of converted samples per channel.
+func floatingAsSigned(src signal.Floating, dst signal.Signed, dither DitherType) int {
+	msv := dst.BitDepth().MaxSignedValue()
+	length := src.Length()
+	if dst.Length() < length {
+		length = dst.Length()
+	}
+	for i := 0; i < length*src.Channels(); i++ {
+		dst.SetSample(i, quantizeDither(src.Sample(i), msv, dither))
+	}
+	return length
+}
+
+// floatingAsUnsigned converts floating samples into unsigned fixed-point.
+// Values beyond [-1, 1] are clipped to the range of the bit depth. It
+// returns the number of converted samples per channel.
+func floatingAsUnsigned(src signal.Floating, dst signal.Unsigned, dither DitherType) int {
+	msv := dst.BitDepth().MaxSignedValue()
+	length := src.Length()
+	if dst.Length() < length {
+		length = dst.Length()
+	}
+	for i := 0; i < length*src.Channels(); i++ {
+		dst.SetSample(i, uint64(quantizeDither(src.Sample(i), msv, dither)+msv+1))
+	}
+	return length
+}
+
+// Normalize scales the asset signal in place, so its peak reaches the
+// target level in dBFS. Silent assets are left intact.
+func (a *Asset) Normalize(targetPeak float64) error {
+	if targetPeak > 0 {
+		return ErrPeakAboveFullScale
+	}
+	if a.Signal == nil {
+		return nil
+	}
+	get, set := sampleAccessors(a.Signal)
+	var peak float64
+	for i := 0; i < a.Signal.Len(); i++ {
+		if v := math.Abs(get(i)); v > peak {
+			peak = v
+		}
+	}
+	if peak == 0 {
+		return nil
+	}
+	gain := math.Pow(10, targetPeak/20) / peak
+	for i := 0; i < a.Signal.Len(); i++ {
+		set(i, get(i)*gain)
+	}
+	return nil
+}
+
+// ApplyChannelGains scales every channel of the asset signal in place by
+// its gain. Number of gains must match the number of channels.
+func (a *Asset) ApplyChannelGains(gains []float64) error {
+	if a.Signal == nil {
+		return nil
+	}
+	channels := a.Signal.Channels()
+	if len(gains) != channels {
+		return ErrChannelGains
+	}
+	get, set := sampleAccessors(a.Signal)
+	for i := 0; i < a.Signal.Len(); i++ {
+		set(i, get(i)*gains[i%channels])
+	}
+	return nil
+}
+
+// Append appends the signal of other asset to the receiver. The signal
+// of other asset is converted to the receiver signal type. If receiver
+// has no signal, float64 buffer is allocated. The receiver signal is
+// copied into a new buffer, so appending to the view returned by Slice
+// or TrimSilence doesn't overwrite the parent asset.
+func (a *Asset) Append(other *Asset) error {
+	if other == nil {
+		return ErrNilAsset
+	}
+	if other.Signal == nil {
+		return nil
+	}
+	if a.Signal == nil {
+		a.sampleRate = other.sampleRate
+		a.Signal = signal.Allocator{
+			Channels: other.Signal.Channels(),
+		}.Float64()
+	}
+	if a.sampleRate != other.sampleRate {
+		return ErrDifferentSampleRates
+	}
+	if a.Signal.Channels() != other.Signal.Channels() {
+		return ErrDifferentChannels
+	}
+	alloc := signal.Allocator{
+		Channels: other.Signal.Channels(),
+		Length:   other.Signal.Length(),
+		Capacity: other.Signal.Length(),
+	}
+	grow := signal.Allocator{
+		Channels: a.Signal.Channels(),
+		Length:   a.Signal.Length(),
+		Capacity: a.Signal.Length() + other.Signal.Length(),
+	}
+	switch data := a.Signal.(type) {
+	case signal.Signed:
+		tail := alloc.Int64(data.BitDepth())
+		signal.AsSigned(other.Signal, tail)
+		result := grow.Int64(data.BitDepth())
+		signal.SignedAsSigned(data, result)
+		result.Append(tail)
+		a.Signal = result
+	case signal.Unsigned:
+		tail := alloc.Uint64(data.BitDepth())
+		signal.AsUnsigned(other.Signal, tail)
+		result := grow.Uint64(data.BitDepth())
+		signal.UnsignedAsUnsigned(data, result)
+		result.Append(tail)
+		a.Signal = result
+	case signal.Floating:
+		tail := alloc.Float64()
+		signal.AsFloating(other.Signal, tail)
+		result := grow.Float64()
+		signal.FloatingAsFloating(data, result)
+		result.Append(tail)
+		a.Signal = result
+	}
+	return nil
+}
+
+// Slice returns a new asset with [from, to) range of the signal. The
+// signal data is shared with the receiver, so changes to one asset are
+// visible in another. Use CopySlice to get independent asset.
+func (a *Asset) Slice(from, to int) *Asset {
+	return &Asset{
+		Signal:     signal.Slice(a.Signal, from, to),
+		sampleRate: a.sampleRate,
+	}
+}
+
+// CopySlice returns a new asset with a copy of [from, to) range of the
+// signal. Signed, unsigned and floating signals keep their kind and bit
+// depth.
+func (a *Asset) CopySlice(from, to int) *Asset {
+	return &Asset{
+		Signal:     copySignal(signal.Slice(a.Signal, from, to)),
+		sampleRate: a.sampleRate,
+	}
+}
+
+// TrimTo trims the asset signal to [from, to) range. Unlike Slice, it
+// modifies the asset and copies the range into a new buffer, so the rest
+// of the signal can be garbage collected.
+func (a *Asset) TrimTo(from, to int) error {
+	length := 0
+	if a.Signal != nil {
+		length = a.Signal.Length()
+	}
+	if from < 0 || to > length || from > to {
+		return ErrInvalidRange
+	}
+	if a.Signal == nil {
+		return nil
+	}
+	a.Signal = copySignal(signal.Slice(a.Signal, from, to))
+	return nil
+}
+
+// TrimSilence removes leading and trailing samples where every channel
+// stays below the threshold. The threshold is a linear amplitude. The
+// signal is resliced, so no data is copied. Entirely silent asset becomes
+// empty.
+func (a *Asset) TrimSilence(threshold float64) {
+	if a.Signal == nil {
+		return
+	}
+	get, _ := sampleAccessors(a.Signal)
+	audible := func(i int) bool {
+		for c := 0; c < a.Signal.Channels(); c++ {
+			if math.Abs(get(a.Signal.BufferIndex(c, i))) >= threshold {
+				return true
+			}
+		}
+		return false
+	}
+	start, end := 0, a.Signal.Length()
+	for start < end && !audible(start) {
+		start++
+	}
+	for end > start && !audible(end-1) {
+		end--
+	}
+	a.Signal = signal.Slice(a.Signal, start, end)
+}
+
+// Peaks returns minimum and maximum values of every bucket of the asset
+// signal. Channels are summed per frame before the peaks are taken, so
+// values can exceed the full scale. If buckets exceed the signal length,
+// a pair per frame is returned.
+func (a *Asset) Peaks(buckets int) [][2]float64 {
+	if a.Signal == nil || buckets <= 0 {
+		return nil
+	}
+	length := a.Signal.Length()
+	if buckets > length {
+		buckets = length
+	}
+	get, _ := sampleAccessors(a.Signal)
+	channels := a.Signal.Channels()
+	peaks := make([][2]float64, buckets)
+	for b := range peaks {
+		from, to := b*length/buckets, (b+1)*length/buckets
+		min, max := math.Inf(1), math.Inf(-1)
+		for i := from; i < to; i++ {
+			var v float64
+			for c := 0; c < channels; c++ {
+				v += get(a.Signal.BufferIndex(c, i))
+			}
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+		peaks[b] = [2]float64{min, max}
+	}
+	return peaks
+}
+
+// Equal returns true if assets have the same sample rate, number of
+// channels, length and samples. Signals of different types are compared
+// by their floating-point values. Nil assets are only equal to each other.
+func (a *Asset) Equal(other *Asset) bool {
+	return a.EqualWithTolerance(other, 0)
+}
+
+// EqualWithTolerance is like Equal, but samples are equal if they differ
+// by no more than the tolerance.
+func (a *Asset) EqualWithTolerance(other *Asset, tolerance float64) bool {
+	if a == nil || other == nil {
+		return a == nil && other == nil
+	}
+	if a.sampleRate != other.sampleRate {
+		return false
+	}
+	if a.Signal == nil || other.Signal == nil {
+		return a.Signal == nil && other.Signal == nil
+	}
+	if a.Signal.Channels() != other.Signal.Channels() || a.Signal.Length() != other.Signal.Length() {
+		return false
+	}
+	get, _ := sampleAccessors(a.Signal)
+	getOther, _ := sampleAccessors(other.Signal)
+	for i := 0; i < a.Signal.Len(); i++ {
+		if math.Abs(get(i)-getOther(i)) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Crossfade returns a new asset with b appended to a. Over length samples
+// at the join, a is faded out and b is faded in linearly. If length
+// exceeds one of the assets, it's reduced to the shorter asset length.
+// Assets must be non-nil, have signal, the same sample rate and number of
+// channels.
+func Crossfade(a, b *Asset, length int) (*Asset, error) {
+	if length < 0 {
+		return nil, ErrInvalidCrossfade
+	}
+	if a == nil || b == nil {
+		return nil, ErrNilAsset
+	}
+	if a.Signal == nil || b.Signal == nil {
+		return nil, ErrEmptyAsset
+	}
+	if a.sampleRate != b.sampleRate {
+		return nil, ErrDifferentSampleRates
+	}
+	channels := a.Signal.Channels()
+	if channels != b.Signal.Channels() {
+		return nil, ErrDifferentChannels
+	}
+	if length > a.Signal.Length() {
+		length = a.Signal.Length()
+	}
+	if length > b.Signal.Length() {
+		length = b.Signal.Length()
+	}
+	head := a.Signal.Length() - length
+	out := signal.Allocator{
+		Channels: channels,
+		Length:   head + b.Signal.Length(),
+		Capacity: head + b.Signal.Length(),
+	}.Float64()
+	getA, _ := sampleAccessors(a.Signal)
+	for i := 0; i < a.Signal.Len(); i++ {
+		out.SetSample(i, getA(i))
+	}
+	getB, _ := sampleAccessors(b.Signal)
+	for i := 0; i < b.Signal.Len(); i++ {
+		idx := head*channels + i
+		v := getB(i)
+		if pos := i / channels; pos < length {
+			gain := fadeGain(FadeIn, FadeLinear, pos, length)
+			v = v*gain + out.Sample(idx)*(1-gain)
+		}
+		out.SetSample(idx, v)
+	}
+	return &Asset{
+		Signal:     out,
+		sampleRate: a.sampleRate,
+	}, nil
+}
+
+// copySignal allocates a new buffer of the same kind and bit depth and
+// copies the signal into it.
+func copySignal(s signal.Signal) signal.Signal {
+	if s == nil {
+		return nil
+	}
+	alloc := signal.Allocator{
+		Channels: s.Channels(),
+		Length:   s.Length(),
+		Capacity: s.Length(),
+	}
+	switch src := s.(type) {
+	case signal.Signed:
+		dst := alloc.Int64(src.BitDepth())
+		signal.SignedAsSigned(src, dst)
+		return dst
+	case signal.Unsigned:
+		dst := alloc.Uint64(src.BitDepth())
+		signal.UnsignedAsUnsigned(src, dst)
+		return dst
+	case signal.Floating:
+		dst := alloc.Float64()
+		signal.FloatingAsFloating(src, dst)
+		return dst
+	}
+	return s
+}
+
+// sampleAccessors returns functions to read and write samples of
+// arbitrary signal type as floating-point values. Fixed-point values are
+// mapped to [-1, 1] range and floating values beyond the range are
+// clipped when written to fixed-point signal. Unsigned samples are offset
+// to signed and converted the same way, so read and write are symmetric.
+// Unlike signal.UnsignedAsFloating, which divides every non-zero sample
+// by the maximum signed value, negative values are divided by the
+// maximum signed value plus one.
+func sampleAccessors(s signal.Signal) (get func(int) float64, set func(int, float64)) {
+	switch v := s.(type) {
+	case signal.Signed:
+		msv := v.BitDepth().MaxSignedValue()
+		get = func(i int) float64 {
+			return signedAsFloat(v.Sample(i), msv)
+		}
+		set = func(i int, f float64) {
+			v.SetSample(i, floatAsSigned(f, msv))
+		}
+	case signal.Unsigned:
+		msv := v.BitDepth().MaxSignedValue()
+		offset := uint64(msv) + 1
+		get = func(i int) float64 {
+			return signedAsFloat(int64(v.Sample(i)-offset), msv)
+		}
+		set = func(i int, f float64) {
+			v.SetSample(i, uint64(floatAsSigned(f, msv))+offset)
+		}
+	case signal.Floating:
+		get = v.Sample
+		set = v.SetSample
+	}
+	return
+}
+
+// signedAsFloat converts signed fixed-point value with provided maximum
+// signed value into floating-point.
+func signedAsFloat(v, msv int64) float64 {
+	if v > 0 {
+		return float64(v) / float64(msv)
+	}
+	return float64(v) / (float64(msv) + 1)
+}
+
+// floatAsSigned converts floating-point value into signed fixed-point
+// with provided maximum signed value. Values beyond [-1, 1] are clipped.
+func floatAsSigned(f float64, msv int64) int64 {
+	switch {
+	case f >= 1:
+		return msv
+	case f > 0:
+		return int64(f * float64(msv))
+	case f <= -1:
+		return -msv - 1
+	}
+	return int64(f * (float64(msv) + 1))
+}
@@ -2,7 +2,9 @@ package audio_test
 
 import (
 	"context"
+	"math"
 	"testing"
+	"time"
 
 	"pipelined.dev/audio"
 	"pipelined.dev/pipe"
@@ -10,6 +12,21 @@ import (
 	"pipelined.dev/signal"
 )
 
+func TestNewAssetFromFloat64(t *testing.T) {
+	asset := audio.NewAssetFromFloat64(44100, 2, []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+	assertEqual(t, "sample rate", asset.SampleRate(), signal.Frequency(44100))
+	assertEqual(t, "channels", asset.Signal.Channels(), 2)
+	assertEqual(t, "length", asset.Signal.Length(), 3)
+	result := [][]float64{make([]float64, 3), make([]float64, 3)}
+	signal.ReadStripedFloat64(asset.Signal.(signal.Floating), result)
+	assertEqual(t, "values", result, [][]float64{{0.1, 0.3, 0.5}, {0.2, 0.4, 0.6}})
+
+	defer func() {
+		assertEqual(t, "panic", recover() != nil, true)
+	}()
+	audio.NewAssetFromFloat64(44100, 2, []float64{0.1, 0.2, 0.3})
+}
+
 func TestAssetSink(t *testing.T) {
 	sampleRate := signal.Frequency(44100)
 	tests := []struct {
@@ -123,3 +140,569 @@ func TestAssetSink(t *testing.T) {
 		assertEqual(t, "samples", test.asset.Signal.Length(), test.samples)
 	}
 }
+
+func TestAssetSinkDither(t *testing.T) {
+	const length = 10240
+	// constant signal of 0.3 least significant bit.
+	value := 0.3 / float64(signal.BitDepth8.MaxSignedValue())
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: length,
+	}
+	capture := func(asset *audio.Asset) {
+		p, err := pipe.New(512, pipe.Line{
+			Source: (&mock.Source{
+				Limit:    length,
+				Channels: 1,
+				Value:    value,
+			}).Source(),
+			Sink: asset.Sink(),
+		})
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		assertEqual(t, "length", asset.Signal.Length(), length)
+	}
+	signedMean := func(dither audio.DitherType) float64 {
+		asset := &audio.Asset{Signal: alloc.Int64(signal.BitDepth8), Dither: dither}
+		capture(asset)
+		values := make([]int64, length)
+		signal.ReadInt64(asset.Signal.(signal.Signed), values)
+		var sum float64
+		for _, v := range values {
+			sum += float64(v)
+		}
+		return sum / length
+	}
+	unsignedMean := func(dither audio.DitherType) float64 {
+		asset := &audio.Asset{Signal: alloc.Uint64(signal.BitDepth8), Dither: dither}
+		capture(asset)
+		values := make([]uint64, length)
+		signal.ReadUint64(asset.Signal.(signal.Unsigned), values)
+		var sum float64
+		for _, v := range values {
+			sum += float64(v) - 128
+		}
+		return sum / length
+	}
+	// without dither the signal below least significant bit is lost.
+	assertEqual(t, "signed truncated", signedMean(audio.DitherNone), 0.0)
+	assertEqual(t, "unsigned truncated", unsignedMean(audio.DitherNone), 0.0)
+	assertEqual(t, "signed dithered", math.Abs(signedMean(audio.DitherTPDF)-0.3) < 0.05, true)
+	assertEqual(t, "unsigned dithered", math.Abs(unsignedMean(audio.DitherTPDF)-0.3) < 0.05, true)
+}
+
+func TestAssetSinkClipping(t *testing.T) {
+	values := []float64{1.5, -1.5, 2, -3, 0.5}
+	capture := func(asset *audio.Asset) {
+		floats := signal.Allocator{
+			Channels: 1,
+			Length:   len(values),
+			Capacity: len(values),
+		}.Float64()
+		signal.WriteFloat64(values, floats)
+		p, err := pipe.New(len(values), pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink:   asset.Sink(),
+		})
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	}
+	alloc := signal.Allocator{Channels: 1}
+	signed := func(bitDepth signal.BitDepth) []int64 {
+		asset := &audio.Asset{Signal: alloc.Int64(bitDepth)}
+		capture(asset)
+		result := make([]int64, asset.Signal.Len())
+		signal.ReadInt64(asset.Signal.(signal.Signed), result)
+		return result
+	}
+	assertEqual(t, "signed 16", signed(signal.BitDepth16), []int64{32767, -32768, 32767, -32768, 16383})
+	assertEqual(t, "signed 24", signed(signal.BitDepth24), []int64{8388607, -8388608, 8388607, -8388608, 4194303})
+
+	asset := &audio.Asset{Signal: alloc.Uint64(signal.BitDepth16)}
+	capture(asset)
+	result := make([]uint64, asset.Signal.Len())
+	signal.ReadUint64(asset.Signal.(signal.Unsigned), result)
+	assertEqual(t, "unsigned 16", result, []uint64{65535, 0, 65535, 0, 49151})
+}
+
+func TestAssetComplete(t *testing.T) {
+	complete := &audio.Asset{}
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: audio.SourceSilence(44100, 1, 10),
+			Sink:   complete.Sink(),
+		},
+	)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "complete", complete.Complete, true)
+	assertEqual(t, "complete length", complete.Signal.Length(), 10)
+
+	cancelled := &audio.Asset{}
+	p, _ = pipe.New(2,
+		pipe.Line{
+			Source: audio.SourceSine(44100, 440, 1, 1),
+			Sink:   cancelled.Sink(),
+		},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := p.Start(ctx)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	_ = pipe.Wait(errc)
+	assertEqual(t, "cancelled", cancelled.Complete, false)
+}
+
+func TestAssetNormalize(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   2,
+		Capacity: 2,
+	}
+	floats := alloc.Float64()
+	signal.WriteFloat64([]float64{0.25, -0.5, 0.125, 0}, floats)
+	ints := alloc.Int64(signal.BitDepth16)
+	signal.WriteInt64([]int64{8192, -16384, 4096, 0}, ints)
+	uints := alloc.Uint64(signal.BitDepth16)
+	signal.WriteUint64([]uint64{32768 + 8192, 32768 - 16384, 32768 + 4096, 32768}, uints)
+
+	tests := []struct {
+		asset    *audio.Asset
+		target   float64
+		expected []float64
+		err      error
+		msg      string
+	}{
+		{
+			asset:    &audio.Asset{Signal: floats},
+			expected: []float64{0.5, -1, 0.25, 0},
+			msg:      "floats",
+		},
+		{
+			asset:    &audio.Asset{Signal: ints},
+			expected: []float64{16384.0 / 32767, -1, 8192.0 / 32767, 0},
+			msg:      "ints",
+		},
+		{
+			asset:    &audio.Asset{Signal: uints},
+			expected: []float64{16384.0 / 32767, -1, 8192.0 / 32767, 0},
+			msg:      "uints",
+		},
+		{
+			asset:    &audio.Asset{Signal: alloc.Float64()},
+			expected: []float64{0, 0, 0, 0},
+			msg:      "silence",
+		},
+		{
+			asset:  &audio.Asset{Signal: alloc.Float64()},
+			target: 1,
+			err:    audio.ErrPeakAboveFullScale,
+			msg:    "above full scale",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.asset.Normalize(test.target)
+		assertEqual(t, test.msg+" error", err, test.err)
+		if err != nil {
+			continue
+		}
+		result := alloc.Float64()
+		signal.AsFloating(test.asset.Signal, result)
+		values := make([]float64, result.Len())
+		signal.ReadFloat64(result, values)
+		assertEqual(t, test.msg, values, test.expected)
+	}
+}
+
+func TestAssetApplyChannelGains(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   2,
+		Capacity: 2,
+	}
+	floats := &audio.Asset{Signal: alloc.Float64()}
+	signal.WriteStripedFloat64([][]float64{{1, -0.5}, {1, -0.5}}, floats.Signal.(signal.Floating))
+	assertNil(t, "floats error", floats.ApplyChannelGains([]float64{0.5, 2}))
+	floatValues := [][]float64{make([]float64, 2), make([]float64, 2)}
+	signal.ReadStripedFloat64(floats.Signal.(signal.Floating), floatValues)
+	assertEqual(t, "floats", floatValues, [][]float64{{0.5, -0.25}, {2, -1}})
+
+	ints := &audio.Asset{Signal: alloc.Int64(signal.BitDepth8)}
+	signal.WriteStripedInt64([][]int64{{127, -128}, {127, -128}}, ints.Signal.(signal.Signed))
+	assertNil(t, "ints error", ints.ApplyChannelGains([]float64{0.5, 0}))
+	intValues := [][]int64{make([]int64, 2), make([]int64, 2)}
+	signal.ReadStripedInt64(ints.Signal.(signal.Signed), intValues)
+	assertEqual(t, "ints", intValues, [][]int64{{63, -64}, {0, 0}})
+
+	assertEqual(t, "gains mismatch", floats.ApplyChannelGains([]float64{1}), audio.ErrChannelGains)
+}
+
+func TestAssetTrimSilence(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}
+	floats := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{{0, 0.01, 0.5, 0, 0}, {0, 0, 0, 0.3, 0.01}}, floats)
+	ints := alloc.Int64(signal.BitDepth16)
+	signal.WriteStripedInt64([][]int64{{0, 16384, 0, 0, 0}, {0, 0, 0, -16384, 0}}, ints)
+	uints := alloc.Uint64(signal.BitDepth16)
+	signal.WriteStripedUint64([][]uint64{{32768, 49152, 32768, 32768, 32768}, {32768, 32768, 32768, 32768, 32768}}, uints)
+
+	tests := []struct {
+		asset    *audio.Asset
+		expected [][]float64
+		msg      string
+	}{
+		{
+			asset:    &audio.Asset{Signal: floats},
+			expected: [][]float64{{0.5, 0}, {0, 0.3}},
+			msg:      "floats",
+		},
+		{
+			asset:    &audio.Asset{Signal: ints},
+			expected: [][]float64{{16384.0 / 32767, 0, 0}, {0, 0, -0.5}},
+			msg:      "ints",
+		},
+		{
+			asset:    &audio.Asset{Signal: uints},
+			expected: [][]float64{{16384.0 / 32767}, {0}},
+			msg:      "uints",
+		},
+		{
+			asset:    &audio.Asset{Signal: alloc.Float64()},
+			expected: [][]float64{{}, {}},
+			msg:      "silence",
+		},
+	}
+
+	for _, test := range tests {
+		test.asset.TrimSilence(0.1)
+		length := test.asset.Signal.Length()
+		assertEqual(t, test.msg+" length", length, len(test.expected[0]))
+		result := signal.Allocator{
+			Channels: 2,
+			Length:   length,
+			Capacity: length,
+		}.Float64()
+		signal.AsFloating(test.asset.Signal, result)
+		values := [][]float64{make([]float64, length), make([]float64, length)}
+		signal.ReadStripedFloat64(result, values)
+		assertEqual(t, test.msg, values, test.expected)
+	}
+}
+
+func TestAssetSetSampleRate(t *testing.T) {
+	captured := &audio.Asset{}
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels:   1,
+				Limit:      2,
+				SampleRate: 44100,
+			}).Source(),
+			Sink: captured.Sink(),
+		},
+	)
+	_ = pipe.Wait(p.Start(context.Background()))
+
+	synthesized := &audio.Asset{
+		Signal: signal.Allocator{
+			Channels: 1,
+			Length:   2,
+			Capacity: 2,
+		}.Float64(),
+	}
+	assertEqual(t, "append without sample rate", captured.Append(synthesized), audio.ErrDifferentSampleRates)
+
+	synthesized.SetSampleRate(44100)
+	assertEqual(t, "sample rate", synthesized.SampleRate(), signal.Frequency(44100))
+	assertNil(t, "append with sample rate", captured.Append(synthesized))
+	assertEqual(t, "length", captured.Signal.Length(), 4)
+}
+
+func TestAssetAppend(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Length:   2,
+		Capacity: 2,
+	}
+	floats := func() signal.Floating {
+		s := alloc.Float64()
+		signal.WriteFloat64([]float64{-1, 1}, s)
+		return s
+	}
+	ints := func() signal.Signed {
+		s := alloc.Int64(signal.BitDepth16)
+		signal.WriteInt64([]int64{32767, -16384}, s)
+		return s
+	}
+	captured := &audio.Asset{}
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels:   1,
+				Limit:      2,
+				SampleRate: 44100,
+			}).Source(),
+			Sink: captured.Sink(),
+		},
+	)
+	_ = pipe.Wait(p.Start(context.Background()))
+
+	tests := []struct {
+		asset    *audio.Asset
+		other    *audio.Asset
+		expected []float64
+		err      error
+		msg      string
+	}{
+		{
+			asset:    &audio.Asset{Signal: floats()},
+			other:    &audio.Asset{Signal: ints()},
+			expected: []float64{-1, 1, 1, -0.5},
+			msg:      "floats with ints",
+		},
+		{
+			asset:    &audio.Asset{Signal: ints()},
+			other:    &audio.Asset{Signal: floats()},
+			expected: []float64{1, -0.5, -1, 1},
+			msg:      "ints with floats",
+		},
+		{
+			asset:    &audio.Asset{},
+			other:    &audio.Asset{Signal: floats()},
+			expected: []float64{-1, 1},
+			msg:      "empty with floats",
+		},
+		{
+			asset: &audio.Asset{Signal: floats()},
+			other: &audio.Asset{
+				Signal: signal.Allocator{Channels: 2, Length: 2, Capacity: 2}.Float64(),
+			},
+			err: audio.ErrDifferentChannels,
+			msg: "different channels",
+		},
+		{
+			asset: &audio.Asset{Signal: floats()},
+			other: captured,
+			err:   audio.ErrDifferentSampleRates,
+			msg:   "different sample rates",
+		},
+		{
+			asset: &audio.Asset{Signal: floats()},
+			err:   audio.ErrNilAsset,
+			msg:   "nil",
+		},
+	}
+
+	for _, test := range tests {
+		err := test.asset.Append(test.other)
+		assertEqual(t, test.msg+" error", err, test.err)
+		if err != nil {
+			continue
+		}
+		result := signal.Allocator{
+			Channels: 1,
+			Length:   test.asset.Signal.Length(),
+			Capacity: test.asset.Signal.Length(),
+		}.Float64()
+		signal.AsFloating(test.asset.Signal, result)
+		values := make([]float64, result.Len())
+		signal.ReadFloat64(result, values)
+		assertEqual(t, test.msg, values, test.expected)
+	}
+
+	// appending to the view doesn't overwrite the parent.
+	parent := audio.NewAssetFromFloat64(44100, 1, []float64{1, 2, 3, 4})
+	view := parent.Slice(0, 2)
+	assertNil(t, "view error", view.Append(audio.NewAssetFromFloat64(44100, 1, []float64{5, 6})))
+	assertEqual(t, "view", view.Equal(audio.NewAssetFromFloat64(44100, 1, []float64{1, 2, 5, 6})), true)
+	assertEqual(t, "parent", parent.Equal(audio.NewAssetFromFloat64(44100, 1, []float64{1, 2, 3, 4})), true)
+}
+
+func TestAssetSlice(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   3,
+		Capacity: 3,
+	}
+	floats := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{{0.1, 0.2, 0.3}, {0.4, 0.5, 0.6}}, floats)
+	ints := alloc.Int64(signal.BitDepth16)
+	signal.WriteStripedInt64([][]int64{{1, 2, 3}, {4, 5, 6}}, ints)
+
+	read := func(s signal.Signal) []float64 {
+		result := signal.Allocator{
+			Channels: s.Channels(),
+			Length:   s.Length(),
+			Capacity: s.Length(),
+		}.Float64()
+		signal.AsFloating(s, result)
+		values := make([]float64, result.Len())
+		signal.ReadFloat64(result, values)
+		return values
+	}
+
+	for _, asset := range []*audio.Asset{{Signal: floats}, {Signal: ints}} {
+		expected := read(asset.Signal)[2:4]
+		view := asset.Slice(1, 2)
+		copied := asset.CopySlice(1, 2)
+		assertEqual(t, "view", read(view.Signal), expected)
+		assertEqual(t, "copy", read(copied.Signal), expected)
+		assertEqual(t, "sample rate", copied.SampleRate(), asset.SampleRate())
+
+		// change the original asset.
+		_ = asset.Normalize(0)
+		assertEqual(t, "view after change", read(view.Signal), read(asset.Signal)[2:4])
+		assertEqual(t, "copy after change", read(copied.Signal), expected)
+	}
+}
+
+func TestAssetTrimTo(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   4,
+		Capacity: 4,
+	}
+	floats := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{{0.1, 0.2, 0.3, 0.4}, {0.5, 0.6, 0.7, 0.8}}, floats)
+	ints := alloc.Int64(signal.BitDepth16)
+	signal.WriteStripedInt64([][]int64{{1, 2, 3, 4}, {5, 6, 7, 8}}, ints)
+	uints := alloc.Uint64(signal.BitDepth16)
+	signal.WriteStripedUint64([][]uint64{{1, 2, 3, 4}, {5, 6, 7, 8}}, uints)
+
+	read := func(s signal.Signal) []float64 {
+		result := signal.Allocator{
+			Channels: s.Channels(),
+			Length:   s.Length(),
+			Capacity: s.Length(),
+		}.Float64()
+		signal.AsFloating(s, result)
+		values := make([]float64, result.Len())
+		signal.ReadFloat64(result, values)
+		return values
+	}
+
+	for _, asset := range []*audio.Asset{{Signal: floats}, {Signal: ints}, {Signal: uints}} {
+		assertEqual(t, "negative from", asset.TrimTo(-1, 2), audio.ErrInvalidRange)
+		assertEqual(t, "to beyond length", asset.TrimTo(1, 5), audio.ErrInvalidRange)
+		assertEqual(t, "from after to", asset.TrimTo(3, 2), audio.ErrInvalidRange)
+
+		expected := read(asset.Signal)[2:6]
+		assertNil(t, "error", asset.TrimTo(1, 3))
+		assertEqual(t, "values", read(asset.Signal), expected)
+		assertEqual(t, "capacity", asset.Signal.Capacity(), 2)
+	}
+}
+
+func TestAssetPeaks(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}
+	floats := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{0.1, -0.2, 0.3, 0.5, 0},
+		{0, 0.4, -0.6, 0, -0.1},
+	}, floats)
+	ints := alloc.Int64(signal.BitDepth8)
+	signal.WriteStripedInt64([][]int64{
+		{0, 64, -128, 0, 0},
+		{0, 0, 0, 127, 0},
+	}, ints)
+
+	asset := &audio.Asset{Signal: floats}
+	// channels are summed per frame: 0.1, 0.2, -0.3, 0.5, -0.1.
+	assertEqual(t, "two buckets", asset.Peaks(2), [][2]float64{{0.1, 0.2}, {-0.3, 0.5}})
+	assertEqual(t, "single bucket", asset.Peaks(1), [][2]float64{{-0.3, 0.5}})
+	assertEqual(t, "more buckets than samples", asset.Peaks(10), [][2]float64{
+		{0.1, 0.1}, {0.2, 0.2}, {-0.3, -0.3}, {0.5, 0.5}, {-0.1, -0.1},
+	})
+	assertEqual(t, "zero buckets", len(asset.Peaks(0)), 0)
+	assertEqual(t, "empty asset", len((&audio.Asset{}).Peaks(2)), 0)
+	assertEqual(t, "ints", (&audio.Asset{Signal: ints}).Peaks(1), [][2]float64{{-1, 1}})
+	signal.WriteStripedInt64([][]int64{{-64}, {-64}}, ints)
+	assertEqual(t, "summed ints", (&audio.Asset{Signal: ints.Slice(0, 1)}).Peaks(1), [][2]float64{{-1, -1}})
+}
+
+func TestAssetEqual(t *testing.T) {
+	floats := audio.NewAssetFromFloat64(44100, 2, []float64{0.5, -0.5, 0.25, 0})
+	ints := &audio.Asset{Signal: signal.Allocator{
+		Channels: 2,
+		Length:   2,
+		Capacity: 2,
+	}.Int64(signal.BitDepth8)}
+	signal.WriteInt64([]int64{64, -64, 32, 0}, ints.Signal.(signal.Signed))
+	ints.SetSampleRate(44100)
+
+	assertEqual(t, "same", floats.Equal(audio.NewAssetFromFloat64(44100, 2, []float64{0.5, -0.5, 0.25, 0})), true)
+	assertEqual(t, "different types", floats.Equal(ints), false)
+	assertEqual(t, "different types with tolerance", floats.EqualWithTolerance(ints, 0.01), true)
+	assertEqual(t, "different sample", floats.Equal(audio.NewAssetFromFloat64(44100, 2, []float64{0.5, -0.5, 0.25, 0.1})), false)
+	assertEqual(t, "different sample rate", floats.Equal(audio.NewAssetFromFloat64(48000, 2, []float64{0.5, -0.5, 0.25, 0})), false)
+	assertEqual(t, "different channels", floats.Equal(audio.NewAssetFromFloat64(44100, 1, []float64{0.5, -0.5, 0.25, 0})), false)
+	assertEqual(t, "different length", floats.Equal(audio.NewAssetFromFloat64(44100, 2, []float64{0.5, -0.5})), false)
+	assertEqual(t, "empty", (&audio.Asset{}).Equal(&audio.Asset{}), true)
+	assertEqual(t, "empty and not empty", (&audio.Asset{}).Equal(floats), false)
+	assertEqual(t, "nil", floats.Equal(nil), false)
+	assertEqual(t, "nil with tolerance", floats.EqualWithTolerance(nil, 0.01), false)
+	assertEqual(t, "both nil", (*audio.Asset)(nil).Equal(nil), true)
+}
+
+func TestCrossfade(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   4,
+		Capacity: 4,
+	}
+	a := &audio.Asset{Signal: alloc.Float64()}
+	signal.WriteStripedFloat64([][]float64{{1, 1, 1, 1}, {0.5, 0.5, 0.5, 0.5}}, a.Signal.(signal.Floating))
+	a.SetSampleRate(44100)
+	b := &audio.Asset{Signal: alloc.Int64(signal.BitDepth8)}
+	signal.WriteStripedInt64([][]int64{{-128, -128, -128, -128}, {0, 0, 0, 0}}, b.Signal.(signal.Signed))
+	b.SetSampleRate(44100)
+
+	crossfade := func(length int, expected [][]float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			result, err := audio.Crossfade(a, b, length)
+			assertNil(t, "error", err)
+			assertEqual(t, "sample rate", result.SampleRate(), signal.Frequency(44100))
+			values := [][]float64{make([]float64, result.Signal.Length()), make([]float64, result.Signal.Length())}
+			signal.ReadStripedFloat64(result.Signal.(signal.Floating), values)
+			assertEqual(t, "result", values, expected)
+		}
+	}
+	t.Run("no crossfade", crossfade(0, [][]float64{
+		{1, 1, 1, 1, -1, -1, -1, -1},
+		{0.5, 0.5, 0.5, 0.5, 0, 0, 0, 0},
+	}))
+	t.Run("crossfade", crossfade(2, [][]float64{
+		{1, 1, 1, 0, -1, -1},
+		{0.5, 0.5, 0.5, 0.25, 0, 0},
+	}))
+	t.Run("crossfade beyond length", crossfade(10, [][]float64{
+		{1, 0.5, 0, -0.5},
+		{0.5, 0.375, 0.25, 0.125},
+	}))
+
+	_, err := audio.Crossfade(a, &audio.Asset{Signal: signal.Allocator{Channels: 1, Length: 1, Capacity: 1}.Float64()}, 1)
+	assertEqual(t, "different sample rates", err, audio.ErrDifferentSampleRates)
+	mono := &audio.Asset{Signal: signal.Allocator{Channels: 1, Length: 1, Capacity: 1}.Float64()}
+	mono.SetSampleRate(44100)
+	_, err = audio.Crossfade(a, mono, 1)
+	assertEqual(t, "different channels", err, audio.ErrDifferentChannels)
+	_, err = audio.Crossfade(a, b, -1)
+	assertEqual(t, "negative length", err, audio.ErrInvalidCrossfade)
+	_, err = audio.Crossfade(a, &audio.Asset{}, 1)
+	assertEqual(t, "nil signal b", err, audio.ErrEmptyAsset)
+	_, err = audio.Crossfade(&audio.Asset{}, b, 1)
+	assertEqual(t, "nil signal a", err, audio.ErrEmptyAsset)
+	_, err = audio.Crossfade(nil, b, 1)
+	assertEqual(t, "nil a", err, audio.ErrNilAsset)
+	_, err = audio.Crossfade(a, nil, 1)
+	assertEqual(t, "nil b", err, audio.ErrNilAsset)
+}
@@ -0,0 +1,71 @@
+package audio
+
+import (
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+type (
+	// CaptureSink is a sink that stores the signal as Asset does and
+	// measures its statistics.
+	CaptureSink struct {
+		Asset
+		stats   CaptureStats
+		squares float64
+	}
+
+	// CaptureStats contains statistics of the captured signal.
+	CaptureStats struct {
+		// Messages is a number of received buffers.
+		Messages int
+		// Samples is a number of received samples per channel.
+		Samples int
+		// Peak is the maximum absolute sample value across all channels.
+		Peak float64
+		// RMS is the root mean square across all channels.
+		RMS float64
+	}
+)
+
+// Sink stores the signal and updates statistics. Statistics are reset
+// every time sink is allocated.
+func (c *CaptureSink) Sink() pipe.SinkAllocatorFunc {
+	assetSink := c.Asset.Sink()
+	return func(m mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		sink, err := assetSink(m, bufferSize, props)
+		if err != nil {
+			return pipe.Sink{}, err
+		}
+		c.stats = CaptureStats{}
+		c.squares = 0
+		sinkFn := sink.SinkFunc
+		sink.SinkFunc = func(in signal.Floating) error {
+			c.measure(in)
+			return sinkFn(in)
+		}
+		return sink, nil
+	}
+}
+
+// Stats returns statistics of the captured signal.
+func (c *CaptureSink) Stats() CaptureStats {
+	return c.stats
+}
+
+func (c *CaptureSink) measure(in signal.Floating) {
+	for i := 0; i < in.Len(); i++ {
+		v := in.Sample(i)
+		c.squares += v * v
+		if abs := math.Abs(v); abs > c.stats.Peak {
+			c.stats.Peak = abs
+		}
+	}
+	c.stats.Messages++
+	c.stats.Samples += in.Length()
+	if total := c.stats.Samples * in.Channels(); total > 0 {
+		c.stats.RMS = math.Sqrt(c.squares / float64(total))
+	}
+}
@@ -0,0 +1,40 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/signal"
+)
+
+func TestCaptureSink(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{0.5, -0.5, 0.5, -0.5, 0.5},
+		{-1, 0.5, 0, 0, 0},
+	}, floats)
+
+	capture := &audio.CaptureSink{}
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink:   capture.Sink(),
+		},
+	)
+	_ = pipe.Wait(p.Start(context.Background()))
+
+	assertEqual(t, "stats", capture.Stats(), audio.CaptureStats{
+		Messages: 3,
+		Samples:  5,
+		Peak:     1,
+		RMS:      0.5,
+	})
+	assertEqual(t, "sample rate", capture.SampleRate(), signal.Frequency(44100))
+	assertEqual(t, "length", capture.Signal.Length(), 5)
+}
@@ -0,0 +1,57 @@
+package audio
+
+import (
+	"errors"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrNotMono is returned when mono processor is bound to a signal with
+// more than one channel.
+var ErrNotMono = errors.New("signal is not mono")
+
+// ToStereo provides processor that duplicates mono signal into two
+// channels.
+func ToStereo() pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		if props.Channels != 1 {
+			return pipe.Processor{}, ErrNotMono
+		}
+		props.Channels = 2
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					v := in.Sample(i)
+					out.SetSample(out.BufferIndex(0, i), v)
+					out.SetSample(out.BufferIndex(1, i), v)
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
+
+// ToMono provides processor that averages all channels of the signal into
+// a single one.
+func ToMono() pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		channels := props.Channels
+		props.Channels = 1
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					var sum float64
+					for c := 0; c < channels; c++ {
+						sum += in.Sample(in.BufferIndex(c, i))
+					}
+					out.SetSample(i, sum/float64(channels))
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,79 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestChannelsConversion(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Length:   3,
+		Capacity: 3,
+	}
+	mono := alloc.Float64()
+	signal.WriteFloat64([]float64{0.1, 0.2, 0.3}, mono)
+	alloc.Channels = 2
+	stereo := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{{0.25, 0.5, 1}, {0.75, 0, 0.5}}, stereo)
+
+	tests := []struct {
+		data      signal.Floating
+		processor pipe.ProcessorAllocatorFunc
+		channels  int
+		expected  []float64
+		msg       string
+	}{
+		{
+			data:      mono,
+			processor: audio.ToStereo(),
+			channels:  2,
+			expected:  []float64{0.1, 0.1, 0.2, 0.2, 0.3, 0.3},
+			msg:       "mono to stereo",
+		},
+		{
+			data:      stereo,
+			processor: audio.ToMono(),
+			channels:  1,
+			expected:  []float64{0.5, 0.25, 0.75},
+			msg:       "stereo to mono",
+		},
+		{
+			data:      mono,
+			processor: audio.ToMono(),
+			channels:  1,
+			expected:  []float64{0.1, 0.2, 0.3},
+			msg:       "mono to mono",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		sink := &mock.Sink{}
+		p, err := pipe.New(bufferSize,
+			pipe.Line{
+				Source:     audio.Source(44100, test.data),
+				Processors: pipe.Processors(test.processor),
+				Sink:       sink.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		assertEqual(t, test.msg+" channels", sink.Values.Channels(), test.channels)
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestToStereoNotMono(t *testing.T) {
+	_, err := audio.ToStereo()(mutable.Mutable(), 2, pipe.SignalProperties{Channels: 2})
+	assertEqual(t, "error", err, audio.ErrNotMono)
+}
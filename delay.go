@@ -0,0 +1,45 @@
+package audio
+
+import (
+	"errors"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrInvalidDelay is returned when delay processor is allocated with
+// non-positive delay.
+var ErrInvalidDelay = errors.New("delay must be positive")
+
+// Delay provides feedback delay processor. The delayed signal is fed back
+// into the delay line with feedback gain and mixed with the dry signal:
+// mix 0 is dry signal only and mix 1 is delayed signal only. The delay
+// line persists across buffers. Since processor can't produce output
+// after the input ends, the delay tail is not emitted at the end of the
+// stream.
+func Delay(samples int, feedback, mix float64) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		if samples <= 0 {
+			return pipe.Processor{}, ErrInvalidDelay
+		}
+		// circular buffer of interleaved samples.
+		line := make([]float64, samples*props.Channels)
+		pos := 0
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Len(); i++ {
+					dry := in.Sample(i)
+					delayed := line[pos]
+					line[pos] = dry + delayed*feedback
+					out.SetSample(i, dry*(1-mix)+delayed*mix)
+					if pos++; pos == len(line) {
+						pos = 0
+					}
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,59 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestDelay(t *testing.T) {
+	delay := func(samples int, feedback, mix float64, expected [][]float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			// stereo impulse.
+			floats := signal.Allocator{
+				Channels: 2,
+				Length:   len(expected[0]),
+				Capacity: len(expected[0]),
+			}.Float64()
+			floats.SetSample(0, 1)
+			floats.SetSample(1, -1)
+
+			sink := &mock.Sink{}
+			p, _ := pipe.New(3,
+				pipe.Line{
+					Source:     audio.Source(44100, floats),
+					Processors: pipe.Processors(audio.Delay(samples, feedback, mix)),
+					Sink:       sink.Sink(),
+				},
+			)
+			_ = pipe.Wait(p.Start(context.Background()))
+
+			result := [][]float64{make([]float64, len(expected[0])), make([]float64, len(expected[0]))}
+			signal.ReadStripedFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+		}
+	}
+	t.Run("wet only", delay(2, 0, 1, [][]float64{
+		{0, 0, 1, 0, 0, 0, 0},
+		{0, 0, -1, 0, 0, 0, 0},
+	}))
+	t.Run("feedback", delay(2, 0.5, 1, [][]float64{
+		{0, 0, 1, 0, 0.5, 0, 0.25},
+		{0, 0, -1, 0, -0.5, 0, -0.25},
+	}))
+	t.Run("half mix", delay(3, 0, 0.5, [][]float64{
+		{0.5, 0, 0, 0.5, 0, 0, 0},
+		{-0.5, 0, 0, -0.5, 0, 0, 0},
+	}))
+}
+
+func TestDelayInvalid(t *testing.T) {
+	_, err := audio.Delay(0, 0, 1)(mutable.Mutable(), 2, pipe.SignalProperties{Channels: 1})
+	assertEqual(t, "error", err, audio.ErrInvalidDelay)
+}
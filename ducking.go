@@ -0,0 +1,75 @@
+package audio
+
+import (
+	"math"
+
+	"pipelined.dev/signal"
+)
+
+// Ducking attenuates regular mixer inputs while any priority input is
+// active. Zero Amount disables ducking.
+type Ducking struct {
+	// Threshold is the absolute sample value of priority input above
+	// which it's active.
+	Threshold float64
+	// Amount is the attenuation of regular inputs in decibels, e.g. -12.
+	Amount float64
+	// Attack is the number of samples to reach the full attenuation.
+	Attack int
+	// Release is the number of samples to recover from the full
+	// attenuation.
+	Release int
+}
+
+// ducker holds the envelope state of ducking across frames.
+type ducker struct {
+	Ducking
+	// current ducking depth, 0 is none and 1 is full attenuation.
+	level float64
+	gains []float64
+}
+
+// envelope returns gains of regular inputs for every sample of the frame.
+func (d *ducker) envelope(priority []signal.Floating, length int) []float64 {
+	if len(d.gains) < length {
+		d.gains = make([]float64, length)
+	}
+	floor := math.Pow(10, d.Amount/20)
+	for i := 0; i < length; i++ {
+		if isActive(priority, i, d.Threshold) {
+			d.level = approach(d.level, 1, d.Attack)
+		} else {
+			d.level = approach(d.level, 0, d.Release)
+		}
+		d.gains[i] = 1 - d.level*(1-floor)
+	}
+	return d.gains[:length]
+}
+
+// isActive returns true if any channel sample of any frame at the
+// position exceeds the threshold.
+func isActive(frames []signal.Floating, pos int, threshold float64) bool {
+	for _, f := range frames {
+		if pos >= f.Length() {
+			continue
+		}
+		for c := 0; c < f.Channels(); c++ {
+			if math.Abs(f.Sample(f.BufferIndex(c, pos))) > threshold {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// approach moves the level towards the target, so it's reached in
+// provided number of samples.
+func approach(level, target float64, samples int) float64 {
+	if samples <= 0 {
+		return target
+	}
+	if level < target {
+		return math.Min(level+1/float64(samples), target)
+	}
+	return math.Max(level-1/float64(samples), target)
+}
@@ -0,0 +1,102 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestMixerDucking(t *testing.T) {
+	mixer := audio.Mixer{
+		FixedDivisor: 1,
+		Ducking: audio.Ducking{
+			Threshold: 0.1,
+			// attenuate by half.
+			Amount:  20 * math.Log10(0.5),
+			Attack:  2,
+			Release: 4,
+		},
+	}
+	sink := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 1,
+				Limit:    4,
+				Value:    0.5,
+			}).Source(),
+			Sink: mixer.SinkPriority(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 1,
+				Limit:    10,
+				Value:    1,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	expected := []float64{
+		// attack while priority is active.
+		0.5 + 0.75, 0.5 + 0.5, 0.5 + 0.5, 0.5 + 0.5,
+		// release after priority ended.
+		0.625, 0.75, 0.875, 1, 1, 1,
+	}
+	assertEqual(t, "length", len(result), len(expected))
+	for i := range expected {
+		assertEqual(t, "sample", math.Abs(result[i]-expected[i]) < 1e-9, true)
+	}
+}
+
+func TestMixerDuckingBelowThreshold(t *testing.T) {
+	mixer := audio.Mixer{
+		FixedDivisor: 1,
+		Ducking: audio.Ducking{
+			Threshold: 0.5,
+			Amount:    -12,
+		},
+	}
+	sink := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 2,
+				Limit:    4,
+				Value:    0.25,
+			}).Source(),
+			Sink: mixer.SinkPriority(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 2,
+				Limit:    4,
+				Value:    0.5,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, []float64{0.75, 0.75, 0.75, 0.75, 0.75, 0.75, 0.75, 0.75})
+}
@@ -0,0 +1,185 @@
+package audio
+
+import (
+	"io"
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// FadeKind defines the direction of the fade.
+type FadeKind int
+
+const (
+	// FadeIn ramps the gain from silence to unity.
+	FadeIn FadeKind = iota
+	// FadeOut ramps the gain from unity to silence. Fade processor
+	// applies it to the stream head and mutes the rest, use FadeOutTail
+	// to fade out the end of the stream.
+	FadeOut
+)
+
+// FadeCurve defines the shape of the fade gain.
+type FadeCurve int
+
+const (
+	// FadeLinear changes the gain linearly.
+	FadeLinear FadeCurve = iota
+	// FadeExponential changes the gain exponentially over 60 dB range.
+	// Fade in rises slowly and speeds up, fade out drops fast and then
+	// tails off.
+	FadeExponential
+	// FadeLogarithmic is the inverse of FadeExponential. Fade in rises
+	// fast and then slows down.
+	FadeLogarithmic
+	// FadeSCurve follows the half of cosine period, so the gain changes
+	// slowly at both ends of the fade.
+	FadeSCurve
+)
+
+// fadeRange is the ratio of gains at the ends of exponential and
+// logarithmic curves, 60 dB.
+const fadeRange = 1000
+
+// Gain returns the fade in gain at the position normalized to [0, 1].
+// Every curve starts at 0 and ends at 1. Positions beyond the range are
+// clamped.
+func (c FadeCurve) Gain(x float64) float64 {
+	x = math.Max(0, math.Min(1, x))
+	switch c {
+	case FadeExponential:
+		return (math.Pow(fadeRange, x) - 1) / (fadeRange - 1)
+	case FadeLogarithmic:
+		return math.Log1p((fadeRange-1)*x) / math.Log(fadeRange)
+	case FadeSCurve:
+		return (1 - math.Cos(math.Pi*x)) / 2
+	}
+	return x
+}
+
+// Fade provides linear fade processor. The ramp is applied to the first
+// samples of the stream and can span multiple buffers. After the ramp,
+// fade in passes the signal through and fade out mutes it, so fade out
+// ends the stream after samples. Since the processor doesn't know the
+// length of the stream, use FadeOutTail to fade out the stream end.
+func Fade(kind FadeKind, samples int) pipe.ProcessorAllocatorFunc {
+	return FadeWithCurve(kind, FadeLinear, samples)
+}
+
+// FadeWithCurve provides fade processor with the gain that follows the
+// curve. Fade out mirrors the fade in curve.
+func FadeWithCurve(kind FadeKind, curve FadeCurve, samples int) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		pos := 0
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					gain := fadeGain(kind, curve, pos, samples)
+					for c := 0; c < in.Channels(); c++ {
+						idx := in.BufferIndex(c, i)
+						out.SetSample(idx, in.Sample(idx)*gain)
+					}
+					if pos < samples {
+						pos++
+					}
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
+
+// FadeOutTail wraps the source, so its last samples are faded out with
+// the gain that follows the curve. The gain reaches silence at the last
+// sample. The source is read samples ahead to find the stream end, so
+// output is delayed by up to samples. If stream is shorter than samples,
+// it's faded out entirely.
+func FadeOutTail(source pipe.SourceAllocatorFunc, curve FadeCurve, samples int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		s, err := source(mut, bufferSize)
+		if err != nil {
+			return pipe.Source{}, err
+		}
+		f := &tailFader{
+			source: s.SourceFunc,
+			in: signal.Allocator{
+				Channels: s.SignalProperties.Channels,
+				Length:   bufferSize,
+				Capacity: bufferSize,
+			}.Float64(),
+			queue:   make([][]float64, s.SignalProperties.Channels),
+			curve:   curve,
+			samples: samples,
+		}
+		s.SourceFunc = f.read
+		return s, nil
+	}
+}
+
+// tailFader holds the samples read ahead of the output.
+type tailFader struct {
+	source  pipe.SourceFunc
+	in      signal.Floating
+	ended   bool        // source returned io.EOF
+	queue   [][]float64 // read ahead samples per channel
+	curve   FadeCurve
+	samples int
+}
+
+func (f *tailFader) read(out signal.Floating) (int, error) {
+	for !f.ended && len(f.queue[0]) < out.Length()+f.samples {
+		read, err := f.source(f.in)
+		if err == io.EOF {
+			f.ended = true
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		for c := range f.queue {
+			for i := 0; i < read; i++ {
+				f.queue[c] = append(f.queue[c], f.in.Sample(f.in.BufferIndex(c, i)))
+			}
+		}
+	}
+	queued := len(f.queue[0])
+	n := queued
+	if !f.ended {
+		n -= f.samples
+	}
+	if n > out.Length() {
+		n = out.Length()
+	}
+	if n == 0 && f.ended {
+		return 0, io.EOF
+	}
+	for i := 0; i < n; i++ {
+		gain := 1.0
+		if f.ended {
+			// the number of samples left after the current one.
+			gain = fadeGain(FadeIn, f.curve, queued-1-i, f.samples)
+		}
+		for c := range f.queue {
+			out.SetSample(out.BufferIndex(c, i), f.queue[c][i]*gain)
+		}
+	}
+	for c := range f.queue {
+		f.queue[c] = append(f.queue[c][:0], f.queue[c][n:]...)
+	}
+	return n, nil
+}
+
+// fadeGain returns gain at the position of the fade.
+func fadeGain(kind FadeKind, curve FadeCurve, pos, samples int) float64 {
+	x := 1.0
+	if pos < samples {
+		x = float64(pos) / float64(samples)
+	}
+	if kind == FadeOut {
+		return curve.Gain(1 - x)
+	}
+	return curve.Gain(x)
+}
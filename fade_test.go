@@ -0,0 +1,125 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestFade(t *testing.T) {
+	fade := func(kind audio.FadeKind, samples int, expected []float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(3,
+				pipe.Line{
+					Source: (&mock.Source{
+						Channels: 2,
+						Limit:    len(expected),
+						Value:    1,
+					}).Source(),
+					Processors: pipe.Processors(audio.Fade(kind, samples)),
+					Sink:       sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			err = pipe.Wait(p.Start(context.Background()))
+			assertNil(t, "error", err)
+
+			result := [][]float64{make([]float64, len(expected)), make([]float64, len(expected))}
+			signal.ReadStripedFloat64(sink.Values, result)
+			assertEqual(t, "left", result[0], expected)
+			assertEqual(t, "right", result[1], expected)
+		}
+	}
+	t.Run("fade in across buffers", fade(audio.FadeIn, 4, []float64{0, 0.25, 0.5, 0.75, 1, 1, 1}))
+	t.Run("fade out across buffers", fade(audio.FadeOut, 4, []float64{1, 0.75, 0.5, 0.25, 0, 0, 0}))
+	t.Run("fade in longer than stream", fade(audio.FadeIn, 8, []float64{0, 0.125, 0.25, 0.375, 0.5}))
+}
+
+func TestFadeCurve(t *testing.T) {
+	curves := map[string]audio.FadeCurve{
+		"linear":      audio.FadeLinear,
+		"exponential": audio.FadeExponential,
+		"logarithmic": audio.FadeLogarithmic,
+		"s-curve":     audio.FadeSCurve,
+	}
+	const steps = 100
+	for name, curve := range curves {
+		assertEqual(t, name+" start", curve.Gain(0), 0.0)
+		assertEqual(t, name+" end", math.Abs(curve.Gain(1)-1) < 1e-12, true)
+		assertEqual(t, name+" clamped", curve.Gain(2), curve.Gain(1))
+		prev := curve.Gain(0)
+		for i := 1; i <= steps; i++ {
+			gain := curve.Gain(float64(i) / steps)
+			assertEqual(t, name+" monotonic", gain > prev, true)
+			prev = gain
+		}
+	}
+	// exponential curve rises slower than linear and logarithmic faster.
+	assertEqual(t, "exponential", audio.FadeExponential.Gain(0.5) < 0.5, true)
+	assertEqual(t, "logarithmic", audio.FadeLogarithmic.Gain(0.5) > 0.5, true)
+	assertEqual(t, "s-curve", math.Abs(audio.FadeSCurve.Gain(0.5)-0.5) < 1e-12, true)
+}
+
+func TestFadeWithCurve(t *testing.T) {
+	sink := &mock.Sink{}
+	p, err := pipe.New(3,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 1,
+				Limit:    6,
+				Value:    1,
+			}).Source(),
+			Processors: pipe.Processors(audio.FadeWithCurve(audio.FadeOut, audio.FadeExponential, 4)),
+			Sink:       sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	for i, v := range result {
+		expected := 0.0
+		if i < 4 {
+			expected = audio.FadeExponential.Gain(1 - float64(i)/4)
+		}
+		assertEqual(t, "fade out", math.Abs(v-expected) < 1e-12, true)
+	}
+}
+
+func TestFadeOutTail(t *testing.T) {
+	fade := func(curve audio.FadeCurve, samples int, expected []float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(3,
+				pipe.Line{
+					Source: audio.FadeOutTail((&mock.Source{
+						Channels: 2,
+						Limit:    len(expected),
+						Value:    1,
+					}).Source(), curve, samples),
+					Sink: sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := [][]float64{make([]float64, len(expected)), make([]float64, len(expected))}
+			signal.ReadStripedFloat64(sink.Values, result)
+			assertEqual(t, "length", sink.Values.Length(), len(expected))
+			assertEqual(t, "left", result[0], expected)
+			assertEqual(t, "right", result[1], expected)
+		}
+	}
+	t.Run("tail across buffers", fade(audio.FadeLinear, 4, []float64{1, 1, 1, 0.75, 0.5, 0.25, 0}))
+	t.Run("tail longer than stream", fade(audio.FadeLinear, 8, []float64{0.375, 0.25, 0.125, 0}))
+	t.Run("no tail", fade(audio.FadeLinear, 0, []float64{1, 1, 1, 1}))
+}
@@ -0,0 +1,44 @@
+package audio
+
+import (
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// Limiter provides processor that keeps the signal amplitude below the
+// threshold. Samples below threshold-knee are passed unchanged, samples
+// above are smoothly attenuated towards the threshold. Zero knee results
+// into hard clipping. The same static curve is applied to every sample of
+// all channels.
+func Limiter(threshold, knee float64) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Len(); i++ {
+					out.SetSample(i, limit(in.Sample(i), threshold, knee))
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
+
+// limit applies limiter curve to a single sample.
+func limit(v, threshold, knee float64) float64 {
+	if knee > threshold {
+		knee = threshold
+	}
+	start := threshold - knee
+	abs := math.Abs(v)
+	if abs <= start {
+		return v
+	}
+	if knee <= 0 {
+		return math.Copysign(threshold, v)
+	}
+	return math.Copysign(start+knee*math.Tanh((abs-start)/knee), v)
+}
@@ -0,0 +1,57 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestLimiter(t *testing.T) {
+	// ramp from -2 to 2.
+	ramp := make([]float64, 401)
+	for i := range ramp {
+		ramp[i] = -2 + float64(i)*0.01
+	}
+	limiter := func(threshold, knee float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			floats := signal.Allocator{
+				Channels: 1,
+				Length:   len(ramp),
+				Capacity: len(ramp),
+			}.Float64()
+			signal.WriteFloat64(ramp, floats)
+
+			sink := &mock.Sink{}
+			p, _ := pipe.New(64,
+				pipe.Line{
+					Source:     audio.Source(44100, floats),
+					Processors: pipe.Processors(audio.Limiter(threshold, knee)),
+					Sink:       sink.Sink(),
+				},
+			)
+			_ = pipe.Wait(p.Start(context.Background()))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "length", len(result), len(ramp))
+			for i, v := range result {
+				assertEqual(t, "above threshold", math.Abs(v) <= threshold, true)
+				if math.Abs(ramp[i]) <= threshold-knee {
+					assertEqual(t, "below knee", v, ramp[i])
+				}
+				if i > 0 {
+					assertEqual(t, "monotonic", v >= result[i-1], true)
+				}
+			}
+		}
+	}
+	t.Run("soft knee", limiter(0.8, 0.2))
+	t.Run("hard clip", limiter(0.5, 0))
+	t.Run("knee above threshold", limiter(0.5, 1))
+}
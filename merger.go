@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrMergerSourceBound is returned when sink is added to the merger after
+// its source was bound.
+var ErrMergerSourceBound = errors.New("merger source is bound")
+
+// Merger combines multiple mono signals into a single multichannel one.
+// It has multiple sinks and a single source. Every sink is assigned to the
+// output channel in order of sink allocation.
+type Merger struct {
+	initialize sync.Once
+	sampleRate signal.Frequency
+	pool       *signal.PoolAllocator
+	// protect inputs, so adding new input won't cause data race
+	lock   sync.Mutex
+	inputs []*mixerInput
+	bound  bool
+}
+
+func (m *Merger) init(sampleRate signal.Frequency, bufferSize int) func() {
+	return func() {
+		m.sampleRate = sampleRate
+		m.pool = signal.GetPoolAllocator(1, bufferSize, bufferSize)
+	}
+}
+
+func mergerMustAfterSink() {
+	panic("merger source bound before sink")
+}
+
+// Sink provides merger sink allocator. Merger sink receives a mono signal
+// for a single output channel. All sinks must be allocated before the
+// source.
+func (m *Merger) Sink() pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		m.initialize.Do(m.init(props.SampleRate, bufferSize))
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if m.bound {
+			return pipe.Sink{}, ErrMergerSourceBound
+		}
+		if m.sampleRate != props.SampleRate {
+			return pipe.Sink{}, ErrDifferentSampleRates
+		}
+		if props.Channels != 1 {
+			return pipe.Sink{}, ErrNotMono
+		}
+		input := newMixerInput(m.pool, 1)
+		m.inputs = append(m.inputs, input)
+		var sinkCtx context.Context
+		return pipe.Sink{
+			StartFunc: func(ctx context.Context) error {
+				sinkCtx = ctx
+				return nil
+			},
+			SinkFunc: func(floats signal.Floating) error {
+				if ok := input.write.wait(sinkCtx); !ok {
+					return sinkCtx.Err()
+				}
+				input.put(floats)
+				if ok := input.read.notify(sinkCtx); !ok {
+					return sinkCtx.Err()
+				}
+				return nil
+			},
+			FlushFunc: func(ctx context.Context) error {
+				close(input.read)
+				return nil
+			},
+		}, nil
+	}
+}
+
+// Source provides merger source allocator. Merger source outputs signal
+// with channel per sink. Only single source per merger is allowed. Must be
+// called after all sinks, otherwise will panic. Finished inputs are
+// padded with silence until all inputs are done.
+func (m *Merger) Source() pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		m.initialize.Do(mergerMustAfterSink) // check that source is bound after sink.
+		m.lock.Lock()
+		m.bound = true
+		inputs := m.inputs
+		m.lock.Unlock()
+		done := make([]bool, len(inputs))
+		// number of samples written per channel.
+		written := make([]int, len(inputs))
+		var sourceCtx context.Context
+		return pipe.Source{
+			SignalProperties: pipe.SignalProperties{
+				Channels:   len(inputs),
+				SampleRate: m.sampleRate,
+			},
+			StartFunc: func(ctx context.Context) error {
+				sourceCtx = ctx
+				return nil
+			},
+			SourceFunc: func(out signal.Floating) (int, error) {
+				length, live := 0, 0
+				for c, input := range inputs {
+					written[c] = 0
+					if done[c] {
+						continue
+					}
+					if ok := input.read.wait(sourceCtx); !ok {
+						if err := sourceCtx.Err(); err != nil {
+							return 0, err
+						}
+						done[c] = true
+						continue
+					}
+					live++
+					frame := input.take()
+					for i := 0; i < frame.Length(); i++ {
+						out.SetSample(out.BufferIndex(c, i), frame.Sample(i))
+					}
+					written[c] = frame.Length()
+					if frame.Length() > length {
+						length = frame.Length()
+					}
+					if ok := input.write.notify(sourceCtx); !ok {
+						return 0, sourceCtx.Err()
+					}
+				}
+				if live == 0 {
+					return 0, io.EOF
+				}
+				// buffer is reused, so pad done and short channels.
+				for c := range inputs {
+					for i := written[c]; i < length; i++ {
+						out.SetSample(out.BufferIndex(c, i), 0)
+					}
+				}
+				return length, nil
+			},
+			FlushFunc: func(ctx context.Context) error {
+				for _, input := range inputs {
+					input.freeFrames(m.pool)
+				}
+				return nil
+			},
+		}, nil
+	}
+}
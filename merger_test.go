@@ -0,0 +1,139 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestMerger(t *testing.T) {
+	merger := &audio.Merger{}
+	sink := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 1,
+				Limit:    5,
+				Value:    0.5,
+			}).Source(),
+			Sink: merger.Sink(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 1,
+				Limit:    3,
+				Value:    -0.5,
+			}).Source(),
+			Sink: merger.Sink(),
+		},
+		pipe.Line{
+			Source: merger.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	err = pipe.Wait(p.Start(context.Background()))
+	assertNil(t, "error", err)
+
+	assertEqual(t, "channels", sink.Values.Channels(), 2)
+	result := [][]float64{make([]float64, 5), make([]float64, 5)}
+	signal.ReadStripedFloat64(sink.Values, result)
+	assertEqual(t, "result", result, [][]float64{
+		{0.5, 0.5, 0.5, 0.5, 0.5},
+		{-0.5, -0.5, -0.5, 0, 0},
+	})
+}
+
+func TestMergerErrors(t *testing.T) {
+	merger := &audio.Merger{}
+	_, err := merger.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{
+		Channels:   1,
+		SampleRate: 44100,
+	})
+	assertNil(t, "error", err)
+
+	_, err = merger.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{
+		Channels:   2,
+		SampleRate: 44100,
+	})
+	assertEqual(t, "not mono", err, audio.ErrNotMono)
+
+	_, err = merger.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{
+		Channels:   1,
+		SampleRate: 48000,
+	})
+	assertEqual(t, "sample rate", err, audio.ErrDifferentSampleRates)
+
+	_, err = merger.Source()(mutable.Mutable(), 2)
+	assertNil(t, "error", err)
+	_, err = merger.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{
+		Channels:   1,
+		SampleRate: 44100,
+	})
+	assertEqual(t, "bound", err, audio.ErrMergerSourceBound)
+}
+
+func TestMergerContextErrors(t *testing.T) {
+	merger := &audio.Merger{}
+	sink, err := merger.Sink()(mutable.Mutable(), bufferSize, pipe.SignalProperties{
+		Channels:   1,
+		SampleRate: 44100,
+	})
+	assertNil(t, "sink error", err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assertNil(t, "sink start error", sink.StartFunc(ctx))
+	in := signal.Allocator{Channels: 1, Length: bufferSize, Capacity: bufferSize}.Float64()
+	assertNil(t, "first sink error", sink.SinkFunc(in))
+	cancel()
+	// single frame input is full and context is done.
+	assertEqual(t, "second sink error", sink.SinkFunc(in), context.Canceled)
+
+	merger = &audio.Merger{}
+	_, err = merger.Sink()(mutable.Mutable(), bufferSize, pipe.SignalProperties{
+		Channels:   1,
+		SampleRate: 44100,
+	})
+	assertNil(t, "sink error", err)
+	source, err := merger.Source()(mutable.Mutable(), bufferSize)
+	assertNil(t, "source error", err)
+	assertNil(t, "source start error", source.StartFunc(ctx))
+	out := signal.Allocator{Channels: 1, Length: bufferSize, Capacity: bufferSize}.Float64()
+	// no frames sinked and context is done.
+	_, err = source.SourceFunc(out)
+	assertEqual(t, "source error", err, context.Canceled)
+}
+
+func TestMergerShortFrame(t *testing.T) {
+	merger := &audio.Merger{}
+	props := pipe.SignalProperties{Channels: 1, SampleRate: 44100}
+	sink1, err := merger.Sink()(mutable.Mutable(), 2, props)
+	assertNil(t, "sink error", err)
+	sink2, err := merger.Sink()(mutable.Mutable(), 2, props)
+	assertNil(t, "sink error", err)
+	source, err := merger.Source()(mutable.Mutable(), 2)
+	assertNil(t, "source error", err)
+	assertNil(t, "sink start error", sink1.StartFunc(context.Background()))
+	assertNil(t, "sink start error", sink2.StartFunc(context.Background()))
+	assertNil(t, "source start error", source.StartFunc(context.Background()))
+
+	in := signal.Allocator{Channels: 1, Length: 2, Capacity: 2}.Float64()
+	signal.WriteFloat64([]float64{0.5, 0.5}, in)
+	assertNil(t, "sink error", sink1.SinkFunc(in))
+	assertNil(t, "sink error", sink2.SinkFunc(in.Slice(0, 1)))
+
+	// out holds stale values of the previous buffer.
+	out := signal.Allocator{Channels: 2, Length: 2, Capacity: 2}.Float64()
+	signal.WriteFloat64([]float64{1, 1, 1, 1}, out)
+	n, err := source.SourceFunc(out)
+	assertNil(t, "source error", err)
+	assertEqual(t, "length", n, 2)
+	result := make([]float64, out.Len())
+	signal.ReadFloat64(out, result)
+	assertEqual(t, "result", result, []float64{0.5, 0.5, 0.5, 0})
+}
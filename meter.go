@@ -0,0 +1,67 @@
+package audio
+
+import (
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// Meter provides pass-through processor that measures per-channel RMS of
+// the signal. Every time window number of samples is processed, callback
+// is called with RMS values of that window. The window can span multiple
+// buffers. The slice passed to the callback is reused between calls.
+func Meter(window int, cb func(rms []float64)) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		squares := make([]float64, props.Channels)
+		rms := make([]float64, props.Channels)
+		count := 0
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					for c := range squares {
+						v := in.Sample(in.BufferIndex(c, i))
+						squares[c] += v * v
+					}
+					if count++; count == window {
+						for c := range squares {
+							rms[c] = math.Sqrt(squares[c] / float64(window))
+							squares[c] = 0
+						}
+						count = 0
+						cb(rms)
+					}
+				}
+				return signal.FloatingAsFloating(in, out), nil
+			},
+		}, nil
+	}
+}
+
+// ClipDetector provides pass-through processor that counts clipped
+// samples, i.e. samples with absolute value of 1 or above. After every
+// buffer with clipped samples, callback is called with the total count of
+// clipped samples of all channels.
+func ClipDetector(cb func(count int)) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		count := 0
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				clipped := 0
+				for i := 0; i < in.Len(); i++ {
+					if math.Abs(in.Sample(i)) >= 1 {
+						clipped++
+					}
+				}
+				if clipped > 0 {
+					count += clipped
+					cb(count)
+				}
+				return signal.FloatingAsFloating(in, out), nil
+			},
+		}, nil
+	}
+}
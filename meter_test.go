@@ -0,0 +1,75 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestMeter(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   7,
+		Capacity: 7,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{1, -1, 1, -1, 0.5, -0.5, 1},
+		{0, 0, 0, 0, 1, 1, 1},
+	}, floats)
+
+	var measured [][]float64
+	sink := &mock.Sink{}
+	p, _ := pipe.New(3,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Processors: pipe.Processors(audio.Meter(2, func(rms []float64) {
+				measured = append(measured, append([]float64(nil), rms...))
+			})),
+			Sink: sink.Sink(),
+		},
+	)
+	_ = pipe.Wait(p.Start(context.Background()))
+
+	assertEqual(t, "rms", measured, [][]float64{{1, 0}, {1, 0}, {0.5, 1}})
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	expected := make([]float64, floats.Len())
+	signal.ReadFloat64(floats, expected)
+	assertEqual(t, "pass through", result, expected)
+}
+
+func TestClipDetector(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   7,
+		Capacity: 7,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{1, -1, 0.5, 0, 0.5, -0.5, 1.5},
+		{0, 0, 0, 0, 0, 0, -1},
+	}, floats)
+
+	var counts []int
+	sink := &mock.Sink{}
+	p, _ := pipe.New(3,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Processors: pipe.Processors(audio.ClipDetector(func(count int) {
+				counts = append(counts, count)
+			})),
+			Sink: sink.Sink(),
+		},
+	)
+	_ = pipe.Wait(p.Start(context.Background()))
+
+	assertEqual(t, "counts", counts, []int{2, 4})
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	expected := make([]float64, floats.Len())
+	signal.ReadFloat64(floats, expected)
+	assertEqual(t, "pass through", result, expected)
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"io"
+	"math"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mutable"
@@ -13,22 +17,61 @@ import (
 
 var (
 	// ErrDifferentSampleRates is returned when signals with different
-	// sample rates are sinked into mixer.
+	// sample rates are sinked into mixer or appended to asset.
 	ErrDifferentSampleRates = errors.New("sinking different sample rates")
 	// ErrDifferentChannels is returned when signals with different number
-	// of channels are sinked into mixer.
+	// of channels are sinked into mixer or appended to asset.
 	ErrDifferentChannels = errors.New("sinking different channels")
+	// ErrMixerNotInitialized is returned when input is added to the mixer
+	// that has no sinks allocated.
+	ErrMixerNotInitialized = errors.New("mixer is not initialized")
 )
 
-// buffer size for input channel. since we only mix single frame at the
-// time, it's just to prevent from blocking on select for too long.
-const defaultInputBuffer = 2
+// default number of frames buffered per mixer input. With single frame,
+// inputs are mixed in lockstep.
+const defaultInputBuffer = 1
 
 type (
 	// Mixer summs up multiple signals. It has multiple sinks and a single
-	// source.
+	// source. Every output frame waits for a frame of each registered
+	// input, so inputs that start late stay time-aligned with others.
+	// Inputs added to the running mixer start at the next output frame.
 	Mixer struct {
-		// InputBuffer int
+		// number of produced samples, first for atomic alignment.
+		produced int64
+		// FixedDivisor sets the number of inputs the mixed signal is
+		// divided by. If zero, every sample is divided by the number of
+		// inputs that contributed to it, so the level changes when one
+		// of the inputs ends. If set, finished inputs effectively
+		// contribute silence.
+		FixedDivisor int
+		// MasterGain is the gain in decibels applied to the mixed signal
+		// after it's divided. Zero is unity gain. Processors of the mixer
+		// source line, e.g. Limiter, are applied after the master gain.
+		MasterGain float64
+		// InputBuffer is the number of frames each input can buffer
+		// ahead of the mixer source. It allows to decouple fast inputs
+		// from slow source. Default is 1. Must be set before the first
+		// sink is allocated.
+		InputBuffer int
+		// Ducking attenuates inputs while any input allocated with
+		// SinkPriority is active. Must be set before the source is
+		// allocated.
+		Ducking Ducking
+		// StableSum makes the mixer sum inputs in the order their sink
+		// allocators were created, regardless of the order sinks are
+		// allocated. It makes rendered mixes reproducible. Must be set
+		// before the first sink is allocated.
+		StableSum bool
+		// InputTimeout is the time the mixer source waits for a frame of
+		// every input. Input that doesn't deliver a frame within the
+		// timeout is considered stalled and removed from the mix, its
+		// further signal is discarded. Zero means no timeout.
+		InputTimeout time.Duration
+		// MonoSum makes the mixer source output a single channel. The
+		// channels of the mixed signal are averaged after the sum. Must
+		// be set before the source is allocated.
+		MonoSum    bool
 		initialize sync.Once
 		sampleRate signal.Frequency
 		channels   int
@@ -36,31 +79,78 @@ type (
 		// protect inputs, so adding new input won't cause data race
 		lock   sync.Mutex
 		inputs []*mixerInput
+		// id of the next input sink allocator.
+		nextID int
 	}
 
 	// mixerOutput represents a slice of samples to mix.
 	mixerOutput struct {
 		buffer signal.Floating
 		len    int
+		// number of inputs added per sample.
+		inputs []int
 	}
 
+	// mixerInput is a ring of frames. Write mutex holds a token per free
+	// frame and read mutex holds a token per filled frame.
 	mixerInput struct {
-		write  chanMutex
-		read   chanMutex
-		buffer signal.Floating
+		write    chanMutex
+		read     chanMutex
+		frames   []signal.Floating
+		writePos int
+		readPos  int
+		// priority input triggers ducking of others.
+		priority bool
+		// id defines the input position if mixer sum is stable.
+		id int
+		// done is closed when the input is removed due to timeout.
+		done chan struct{}
+		// timer is reset on every wait if the input timeout is set.
+		timer *time.Timer
 	}
 
 	chanMutex chan struct{}
 )
 
-func newMixerInput(buf signal.Floating) mixerInput {
-	write := make(chan struct{}, 1)
-	write <- struct{}{}
-	read := make(chan struct{}, 1)
-	return mixerInput{
+func newMixerInput(p *signal.PoolAllocator, size int) *mixerInput {
+	write := make(chan struct{}, size)
+	frames := make([]signal.Floating, size)
+	for i := range frames {
+		write <- struct{}{}
+		frames[i] = p.Float64()
+	}
+	return &mixerInput{
 		write:  write,
-		read:   read,
-		buffer: buf,
+		read:   make(chan struct{}, size),
+		frames: frames,
+		done:   make(chan struct{}),
+	}
+}
+
+// put copies the signal into the next free frame. Must be called after
+// write mutex is acquired.
+func (in *mixerInput) put(floats signal.Floating) {
+	frame := in.frames[in.writePos]
+	frame = frame.Slice(0, frame.Capacity())
+	if n := signal.FloatingAsFloating(floats, frame); n != frame.Length() {
+		frame = frame.Slice(0, n)
+	}
+	in.frames[in.writePos] = frame
+	in.writePos = (in.writePos + 1) % len(in.frames)
+}
+
+// take returns the next filled frame. Must be called after read mutex is
+// acquired. The frame must not be used after write mutex is notified.
+func (in *mixerInput) take() signal.Floating {
+	frame := in.frames[in.readPos]
+	in.readPos = (in.readPos + 1) % len(in.frames)
+	return frame
+}
+
+// freeFrames puts all frames back to the pool.
+func (in *mixerInput) freeFrames(p *signal.PoolAllocator) {
+	for i := range in.frames {
+		free(in.frames[i], p)
 	}
 }
 
@@ -82,69 +172,199 @@ func (m chanMutex) notify(ctx context.Context) bool {
 	}
 }
 
-func (m *Mixer) init(sampleRate signal.Frequency, channels, bufferSize int) func() {
+func (m *Mixer) init(sampleRate signal.Frequency, channels int) func() {
 	return func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
 		m.channels = channels
 		m.sampleRate = sampleRate
-		m.pool = signal.GetPoolAllocator(channels, bufferSize, bufferSize)
 	}
 }
 
+// allocatePool creates the pool of mixer buffers on the first
+// allocation. Must be called with lock held.
+func (m *Mixer) allocatePool(bufferSize int) {
+	if m.pool == nil {
+		m.pool = signal.GetPoolAllocator(m.channels, bufferSize, bufferSize)
+	}
+}
+
+// Configure sets the sample rate and number of channels of the mixer, so
+// the source can be allocated before sinks. Sinks with different signal
+// properties fail to allocate. It has no effect if the mixer already has
+// sinks allocated.
+func (m *Mixer) Configure(sampleRate signal.Frequency, channels int) {
+	m.initialize.Do(m.init(sampleRate, channels))
+}
+
+func (m *Mixer) inputBuffer() int {
+	if m.InputBuffer > 0 {
+		return m.InputBuffer
+	}
+	return defaultInputBuffer
+}
+
 func mustAfterSink() {
-	panic("mixer source bound before sink")
+	panic("mixer source bound before sink or configuration")
 }
 
 // Sink provides mixer sink allocator. Mixer sink receives a signal for
-// mixing. Multiple sinks per mixer is allowed.
+// mixing. Multiple sinks per mixer is allowed. If the context is done
+// while the sink waits for the mixer, its error is returned.
 func (m *Mixer) Sink() pipe.SinkAllocatorFunc {
+	return m.sinkAllocator(false)
+}
+
+// SinkPriority provides mixer sink allocator for the priority input.
+// While priority input is active, other inputs are attenuated according
+// to the Ducking setting. Priority input itself is never attenuated.
+func (m *Mixer) SinkPriority() pipe.SinkAllocatorFunc {
+	return m.sinkAllocator(true)
+}
+
+func (m *Mixer) sinkAllocator(priority bool) pipe.SinkAllocatorFunc {
+	id := m.inputID()
 	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
-		m.initialize.Do(m.init(props.SampleRate, props.Channels, bufferSize))
+		m.initialize.Do(m.init(props.SampleRate, props.Channels))
 		m.lock.Lock()
 		defer m.lock.Unlock()
-		if m.sampleRate != props.SampleRate {
-			return pipe.Sink{}, ErrDifferentSampleRates
-		}
-		if m.channels != props.Channels {
-			return pipe.Sink{}, ErrDifferentChannels
+		m.allocatePool(bufferSize)
+		return m.sink(props, id, priority)
+	}
+}
+
+// inputID returns the id for a new input sink allocator.
+func (m *Mixer) inputID() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id := m.nextID
+	m.nextID++
+	return id
+}
+
+// AddInput provides sink allocator for the mixer that already has sinks,
+// e.g. to add input to the running mixer. Unlike Sink, it doesn't
+// initialize the mixer and returns ErrMixerNotInitialized if neither sinks
+// nor source were allocated. New input is mixed starting from the next frame.
+func (m *Mixer) AddInput() pipe.SinkAllocatorFunc {
+	id := m.inputID()
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		if m.pool == nil {
+			return pipe.Sink{}, ErrMixerNotInitialized
 		}
-		input := newMixerInput(m.pool.Float64())
-		m.inputs = append(m.inputs, &input)
-		var sinkCtx context.Context
-		return pipe.Sink{
-			StartFunc: func(ctx context.Context) error {
-				sinkCtx = ctx
-				return nil
-			},
-			SinkFunc: func(floats signal.Floating) error {
-				if ok := input.write.wait(sinkCtx); !ok {
-					return nil
-				}
-				n := signal.FloatingAsFloating(floats, input.buffer)
-				if n != bufferSize {
-					input.buffer = input.buffer.Slice(0, n)
-				}
-				input.read.notify(sinkCtx)
+		return m.sink(props, id, false)
+	}
+}
+
+// sink adds a new input to the mixer. Must be called with lock held.
+func (m *Mixer) sink(props pipe.SignalProperties, id int, priority bool) (pipe.Sink, error) {
+	if m.sampleRate != props.SampleRate {
+		return pipe.Sink{}, ErrDifferentSampleRates
+	}
+	if m.channels != props.Channels {
+		return pipe.Sink{}, ErrDifferentChannels
+	}
+	input := newMixerInput(m.pool, m.inputBuffer())
+	input.priority = priority
+	input.id = id
+	m.addInput(input)
+	var sinkCtx context.Context
+	return pipe.Sink{
+		StartFunc: func(ctx context.Context) error {
+			sinkCtx = ctx
+			return nil
+		},
+		SinkFunc: func(floats signal.Floating) error {
+			select {
+			case <-input.done:
+				// stalled input was removed from the mix.
 				return nil
-			},
-			FlushFunc: func(ctx context.Context) error {
-				close(input.read)
+			default:
+			}
+			select {
+			case <-sinkCtx.Done():
+				return sinkCtx.Err()
+			case <-input.done:
 				return nil
-			},
-		}, nil
+			case <-input.write:
+			}
+			input.put(floats)
+			if ok := input.read.notify(sinkCtx); !ok {
+				return sinkCtx.Err()
+			}
+			return nil
+		},
+		FlushFunc: func(ctx context.Context) error {
+			close(input.read)
+			return nil
+		},
+	}, nil
+}
+
+// addInput adds the input to the mixer. If sum is stable, inputs are
+// ordered by id. Must be called with lock held.
+func (m *Mixer) addInput(input *mixerInput) {
+	if !m.StableSum {
+		m.inputs = append(m.inputs, input)
+		return
 	}
+	i := sort.Search(len(m.inputs), func(i int) bool {
+		return m.inputs[i].id > input.id
+	})
+	m.inputs = append(m.inputs, nil)
+	copy(m.inputs[i+1:], m.inputs[i:])
+	m.inputs[i] = input
+}
+
+// InputCount returns the number of live mixer inputs. Inputs are removed
+// once they are flushed and mixed.
+func (m *Mixer) InputCount() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.inputs)
+}
+
+// Produced returns the number of samples per channel produced by the
+// mixer source. It's reset when the source is allocated, so reused mixer
+// reports totals of the current run.
+func (m *Mixer) Produced() int64 {
+	return atomic.LoadInt64(&m.produced)
 }
 
 // Source provides mixer source allocator. Mixer source outputs mixed
-// signal. Only single source per mixer is allowed. Must be called after
-// Sink, otherwise will panic.
+// signal. Only single source per mixer is allowed. Must be allocated
+// after Sink or Configure is called, otherwise will panic. If the context
+// is done while the source waits for inputs, its error is returned.
 func (m *Mixer) Source() pipe.SourceAllocatorFunc {
 	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
 		m.initialize.Do(mustAfterSink) // check that source is bound after sink.
-		output := &mixerOutput{buffer: m.pool.Float64()}
-		var sourceCtx context.Context
+		m.lock.Lock()
+		m.allocatePool(bufferSize)
+		m.lock.Unlock()
+		output := &mixerOutput{
+			buffer: m.pool.Float64(),
+			inputs: make([]int, m.channels*bufferSize),
+		}
+		gain := math.Pow(10, m.MasterGain/20)
+		atomic.StoreInt64(&m.produced, 0)
+		var duck *ducker
+		if m.Ducking.Amount != 0 {
+			duck = &ducker{Ducking: m.Ducking}
+		}
+		channels, mono := m.channels, m.MonoSum
+		if mono {
+			channels = 1
+		}
+		var (
+			sourceCtx context.Context
+			// frames of inputs and priority inputs in the current frame.
+			frames, priority []signal.Floating
+		)
 		return pipe.Source{
 			SignalProperties: pipe.SignalProperties{
-				Channels:   m.channels,
+				Channels:   channels,
 				SampleRate: m.sampleRate,
 			},
 			StartFunc: func(ctx context.Context) error {
@@ -154,20 +374,54 @@ func (m *Mixer) Source() pipe.SourceAllocatorFunc {
 			SourceFunc: func(out signal.Floating) (int, error) {
 				m.lock.Lock()
 				defer m.lock.Unlock()
+				// single input doesn't need to be mixed.
+				if len(m.inputs) == 1 && m.FixedDivisor <= 1 && duck == nil && !mono {
+					n, err := m.passThrough(sourceCtx, gain, out)
+					atomic.AddInt64(&m.produced, int64(n))
+					return n, err
+				}
+				frames, priority = frames[:0], priority[:0]
+				length := 0
 				for i := 0; i < len(m.inputs); {
-					if ok := m.inputs[i].read.wait(sourceCtx); !ok {
-						m.inputs[i].buffer.Free(m.pool)
+					// closed input still delivers the frame it notified
+					// about before flush, so the tail isn't lost.
+					if ok, err := m.waitInput(sourceCtx, m.inputs[i]); !ok {
+						if err != nil {
+							return 0, err
+						}
 						m.inputs = append(m.inputs[:i], m.inputs[i+1:]...)
 						continue
 					}
-					output.add(m.inputs[i].buffer)
-					m.inputs[i].write.notify(sourceCtx)
+					frame := m.inputs[i].take()
+					frames = append(frames, frame)
+					if m.inputs[i].priority {
+						priority = append(priority, frame)
+					}
+					if frame.Length() > length {
+						length = frame.Length()
+					}
 					i++
 				}
 				if len(m.inputs) == 0 {
 					return 0, io.EOF
 				}
-				return output.sum(len(m.inputs), out) / m.channels, nil
+				var gains []float64
+				if duck != nil {
+					gains = duck.envelope(priority, length)
+				}
+				for i, input := range m.inputs {
+					if gains == nil || input.priority {
+						output.add(frames[i])
+					} else {
+						output.addScaled(frames[i], gains)
+					}
+					if ok := input.write.notify(sourceCtx); !ok {
+						return 0, sourceCtx.Err()
+					}
+				}
+				n := output.sum(m.FixedDivisor, gain, out) / m.channels
+				atomic.AddInt64(&m.produced, int64(n))
+				return n, nil
 			},
 			FlushFunc: func(ctx context.Context) error {
 				output.buffer.Free(m.pool)
@@ -177,16 +431,112 @@ func (m *Mixer) Source() pipe.SourceAllocatorFunc {
 	}
 }
 
-// sum returns mixed samplein.
-func (f *mixerOutput) sum(inputs int, out signal.Floating) (summed int) {
-	for i := 0; i < f.buffer.Len(); i++ {
-		out.SetSample(i, f.buffer.Sample(i)/float64(inputs))
+// waitInput waits for the next frame of the input. It returns false if
+// the input is closed or stalled and must be removed from the mix. Frames
+// of the stalled input aren't put back to the pool, because its sink can
+// still write them.
+func (m *Mixer) waitInput(ctx context.Context, input *mixerInput) (bool, error) {
+	var timeout <-chan time.Time
+	if m.InputTimeout > 0 {
+		if input.timer == nil {
+			input.timer = time.NewTimer(m.InputTimeout)
+		} else {
+			input.timer.Reset(m.InputTimeout)
+		}
+		defer stopTimer(input.timer)
+		timeout = input.timer.C
+	}
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case _, ok := <-input.read:
+		if !ok {
+			input.freeFrames(m.pool)
+		}
+		return ok, nil
+	case <-timeout:
+		close(input.done)
+		return false, nil
+	}
+}
+
+// stopTimer stops the timer and drains its channel, so it can be reset.
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// passThrough copies the frame of the single input into the output. The
+// result is the same as mixing, but samples aren't divided. Must be called
+// with lock held.
+func (m *Mixer) passThrough(ctx context.Context, gain float64, out signal.Floating) (int, error) {
+	input := m.inputs[0]
+	if ok, err := m.waitInput(ctx, input); !ok {
+		if err != nil {
+			return 0, err
+		}
+		m.inputs = m.inputs[:0]
+		return 0, io.EOF
+	}
+	frame := input.take()
+	n := signal.FloatingAsFloating(frame, out)
+	if gain != 1 {
+		for i := 0; i < frame.Len(); i++ {
+			out.SetSample(i, out.Sample(i)*gain)
+		}
+	}
+	if ok := input.write.notify(ctx); !ok {
+		return 0, ctx.Err()
+	}
+	return n, nil
+}
+
+// sum returns mixed samplein. If divisor is zero, every sample is divided
+// by the number of inputs added to it. Divided samples are scaled by gain.
+// If output has a single channel, channels of the mix are averaged into
+// it.
+func (f *mixerOutput) sum(divisor int, gain float64, out signal.Floating) (summed int) {
+	channels := f.buffer.Channels()
+	mono := out.Channels() == 1 && channels > 1
+	for i := 0; i < f.len; i++ {
+		d := divisor
+		if d == 0 {
+			d = f.inputs[i]
+		}
+		v := f.buffer.Sample(i) / float64(d) * gain
+		if mono {
+			pos := i / channels
+			if i%channels == 0 {
+				out.SetSample(pos, 0)
+			}
+			out.SetSample(pos, out.Sample(pos)+v/float64(channels))
+		} else {
+			out.SetSample(i, v)
+		}
 		f.buffer.SetSample(i, 0)
+		f.inputs[i] = 0
 	}
 	summed, f.len = f.len, 0
 	return
 }
 
+// addScaled adds the input scaled by the gain of every sample position.
+func (f *mixerOutput) addScaled(in signal.Floating, gains []float64) {
+	if f.len < in.Len() {
+		f.len = in.Len()
+	}
+
+	for i := 0; i < in.Len(); i++ {
+		gain := gains[i/in.Channels()]
+		f.buffer.SetSample(i, f.buffer.Sample(i)+in.Sample(i)*gain)
+		f.inputs[i]++
+	}
+}
+
 func (f *mixerOutput) add(in signal.Floating) {
 	if f.len < in.Len() {
 		f.len = in.Len()
@@ -194,6 +544,13 @@ func (f *mixerOutput) add(in signal.Floating) {
 
 	for i := 0; i < in.Len(); i++ {
 		f.buffer.SetSample(i, f.buffer.Sample(i)+in.Sample(i))
+		f.inputs[i]++
 	}
 	return
 }
+
+// free puts the buffer back to the pool. The buffer is restored to its
+// full length, so samples beyond the sliced length are cleared as well.
+func free(s signal.Floating, p *signal.PoolAllocator) {
+	s.Slice(0, s.Capacity()).Free(p)
+}
@@ -2,8 +2,10 @@ package audio_test
 
 import (
 	"context"
+	"io"
 	"math"
 	"testing"
+	"time"
 
 	"pipelined.dev/audio"
 
@@ -22,10 +24,10 @@ func TestMixer(t *testing.T) {
 		numChannels = 1
 		bufferSize  = 2
 	)
-	mixer := func(generators []generator, expected []float64) func(*testing.T) {
+	mixer := func(divisor int, generators []generator, expected []float64) func(*testing.T) {
 		return func(t *testing.T) {
 			t.Helper()
-			mixer := audio.Mixer{}
+			mixer := audio.Mixer{FixedDivisor: divisor}
 
 			routes := make([]pipe.Line, 0, len(generators)+1)
 			for _, gen := range generators {
@@ -58,6 +60,7 @@ func TestMixer(t *testing.T) {
 	}
 	t.Run("single channel",
 		mixer(
+			0,
 			[]generator{
 				{
 					limit: 4,
@@ -69,6 +72,7 @@ func TestMixer(t *testing.T) {
 	)
 	t.Run("two channels same length",
 		mixer(
+			0,
 			[]generator{
 				{
 					limit: 6,
@@ -84,6 +88,7 @@ func TestMixer(t *testing.T) {
 	)
 	t.Run("two channels short buffer",
 		mixer(
+			0,
 			[]generator{
 				{
 					limit: 5,
@@ -97,6 +102,38 @@ func TestMixer(t *testing.T) {
 			[]float64{0.6, 0.6, 0.6, 0.6, 0.5},
 		),
 	)
+	t.Run("two channels partial last frame",
+		mixer(
+			0,
+			[]generator{
+				{
+					limit: 3,
+					value: 0.7,
+				},
+				{
+					limit: 6,
+					value: 0.5,
+				},
+			},
+			[]float64{0.6, 0.6, 0.6, 0.5, 0.5, 0.5},
+		),
+	)
+	t.Run("two channels short buffer fixed divisor",
+		mixer(
+			2,
+			[]generator{
+				{
+					limit: 5,
+					value: 0.5,
+				},
+				{
+					limit: 4,
+					value: 0.7,
+				},
+			},
+			[]float64{0.6, 0.6, 0.6, 0.6, 0.25},
+		),
+	)
 }
 
 func TestMixerAddInput(t *testing.T) {
@@ -132,6 +169,482 @@ func TestMixerAddInput(t *testing.T) {
 	assertEqual(t, "sink1 samples", sink.Counter.Samples >= 10*bufferSize, true)
 }
 
+func TestMixerAddInputAllocator(t *testing.T) {
+	props := pipe.SignalProperties{Channels: 2, SampleRate: 44100}
+	mixer := &audio.Mixer{}
+	_, err := mixer.AddInput()(mutable.Mutable(), bufferSize, props)
+	assertEqual(t, "not initialized", err, audio.ErrMixerNotInitialized)
+
+	sink := &mock.Sink{}
+	p, _ := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:      10 * bufferSize,
+				Channels:   2,
+				SampleRate: 44100,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	_, err = mixer.AddInput()(mutable.Mutable(), bufferSize, pipe.SignalProperties{Channels: 1, SampleRate: 44100})
+	assertEqual(t, "different channels", err, audio.ErrDifferentChannels)
+	errc := p.Start(context.Background())
+
+	p.Push(p.AddLine(pipe.Line{
+		Source: (&mock.Source{
+			Limit:      10 * bufferSize,
+			Channels:   2,
+			SampleRate: 44100,
+		}).Source(),
+		Sink: mixer.AddInput(),
+	}))
+	assertNil(t, "error", pipe.Wait(errc))
+	assertEqual(t, "messages", sink.Counter.Messages >= 10, true)
+	assertEqual(t, "inputs after end", mixer.InputCount(), 0)
+}
+
+func TestMixerStaggeredStart(t *testing.T) {
+	// late source delays its first frame.
+	late := func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		s, err := (&mock.Source{
+			Limit:    4,
+			Channels: 1,
+			Value:    0.25,
+		}).Source()(mut, bufferSize)
+		sourceFn := s.SourceFunc
+		started := false
+		s.SourceFunc = func(out signal.Floating) (int, error) {
+			if !started {
+				time.Sleep(50 * time.Millisecond)
+				started = true
+			}
+			return sourceFn(out)
+		}
+		return s, err
+	}
+	mixer := audio.Mixer{}
+	sink := mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    4,
+				Channels: 1,
+				Value:    0.5,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: late,
+			Sink:   mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, []float64{0.375, 0.375, 0.375, 0.375})
+}
+
+func TestMixerInputCount(t *testing.T) {
+	mixer := &audio.Mixer{}
+	p, _ := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    10 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    5 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+	)
+	assertEqual(t, "inputs before start", mixer.InputCount(), 2)
+
+	_ = pipe.Wait(p.Start(context.Background()))
+	assertEqual(t, "inputs after end", mixer.InputCount(), 0)
+}
+
+func TestMixerInputBuffer(t *testing.T) {
+	mixer := audio.Mixer{InputBuffer: 4}
+	sink := mock.Sink{}
+	p, err := pipe.New(
+		2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    9,
+				Channels: 1,
+				Value:    0.5,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    4,
+				Channels: 1,
+				Value:    0.25,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, []float64{0.375, 0.375, 0.375, 0.375, 0.5, 0.5, 0.5, 0.5, 0.5})
+}
+
+func TestMixerContextErrors(t *testing.T) {
+	props := pipe.SignalProperties{Channels: 1, SampleRate: 44100}
+	mixer := audio.Mixer{}
+	sink, err := mixer.Sink()(mutable.Mutable(), bufferSize, props)
+	assertNil(t, "sink error", err)
+	source, err := mixer.Source()(mutable.Mutable(), bufferSize)
+	assertNil(t, "source error", err)
+
+	sourceCtx, cancelSource := context.WithCancel(context.Background())
+	assertNil(t, "source start error", source.StartFunc(sourceCtx))
+	cancelSource()
+	out := signal.Allocator{Channels: 1, Length: bufferSize, Capacity: bufferSize}.Float64()
+	// no frames sinked and context is done.
+	_, err = source.SourceFunc(out)
+	assertEqual(t, "source error", err, context.Canceled)
+
+	sinkCtx, cancelSink := context.WithCancel(context.Background())
+	assertNil(t, "sink start error", sink.StartFunc(sinkCtx))
+	in := signal.Allocator{Channels: 1, Length: bufferSize, Capacity: bufferSize}.Float64()
+	assertNil(t, "first sink error", sink.SinkFunc(in))
+	cancelSink()
+	// single frame input is full and context is done.
+	assertEqual(t, "second sink error", sink.SinkFunc(in), context.Canceled)
+}
+
+func TestMixerMasterGain(t *testing.T) {
+	masterGain := func(gain float64, processors []pipe.ProcessorAllocatorFunc, expected float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			mixer := audio.Mixer{MasterGain: gain}
+			sink := mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source: (&mock.Source{
+						Limit:    4,
+						Channels: 1,
+						Value:    0.7,
+					}).Source(),
+					Sink: mixer.Sink(),
+				},
+				pipe.Line{
+					Source:     mixer.Source(),
+					Processors: processors,
+					Sink:       sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "result", result, []float64{expected, expected, expected, expected})
+		}
+	}
+	t.Run("unity", masterGain(0, nil, 0.7))
+	t.Run("attenuate", masterGain(-6, nil, 0.7*math.Pow(10, -6.0/20)))
+	t.Run("gain before limiter", masterGain(6, pipe.Processors(audio.Limiter(1, 0)), 1))
+}
+
+func TestMixerStableSum(t *testing.T) {
+	mix := func(stable bool) float64 {
+		mixer := audio.Mixer{FixedDivisor: 1, StableSum: stable}
+		large := mixer.Sink()
+		negative := mixer.Sink()
+		small := mixer.Sink()
+		line := func(value float64, sink pipe.SinkAllocatorFunc) pipe.Line {
+			return pipe.Line{
+				Source: (&mock.Source{
+					Limit:    2,
+					Channels: 1,
+					Value:    value,
+				}).Source(),
+				Sink: sink,
+			}
+		}
+		sink := mock.Sink{}
+		// sinks are allocated in the order different from creation.
+		p, err := pipe.New(2,
+			line(1, small),
+			line(1e16, large),
+			line(-1e16, negative),
+			pipe.Line{
+				Source: mixer.Source(),
+				Sink:   sink.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		return sink.Values.Sample(0)
+	}
+	assertEqual(t, "unstable", mix(false), 0.0)
+	assertEqual(t, "stable", mix(true), 1.0)
+}
+
+func TestMixerInputTimeout(t *testing.T) {
+	mixer := audio.Mixer{InputTimeout: 100 * time.Millisecond}
+	// stalled source delivers a single buffer after the mixer output is
+	// done, so it's always removed by the timeout.
+	release := make(chan struct{})
+	stalled := func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		called := false
+		return pipe.Source{
+			SourceFunc: func(out signal.Floating) (int, error) {
+				if called {
+					return 0, io.EOF
+				}
+				called = true
+				<-release
+				return out.Length(), nil
+			},
+			SignalProperties: pipe.SignalProperties{
+				Channels: 1,
+			},
+		}, nil
+	}
+	sink := mock.Sink{}
+	output := func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		s, err := sink.Sink()(mut, bufferSize, props)
+		flush := s.FlushFunc
+		s.FlushFunc = func(ctx context.Context) error {
+			close(release)
+			if flush != nil {
+				return flush(ctx)
+			}
+			return nil
+		}
+		return s, err
+	}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    4,
+				Channels: 1,
+				Value:    0.5,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+		pipe.Line{
+			Source: stalled,
+			Sink:   mixer.Sink(),
+		},
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   output,
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, []float64{0.5, 0.5, 0.5, 0.5})
+}
+
+func TestMixerConfigure(t *testing.T) {
+	mixer := audio.Mixer{}
+	mixer.Configure(44100, 1)
+	sink := mock.Sink{}
+	// source line is allocated before sinks.
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		},
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:      4,
+				Channels:   1,
+				Value:      0.5,
+				SampleRate: 44100,
+			}).Source(),
+			Sink: mixer.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, []float64{0.5, 0.5, 0.5, 0.5})
+
+	_, err = mixer.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{SampleRate: 44100, Channels: 2})
+	assertEqual(t, "different channels", err, audio.ErrDifferentChannels)
+	_, err = mixer.Sink()(mutable.Mutable(), 2, pipe.SignalProperties{SampleRate: 48000, Channels: 1})
+	assertEqual(t, "different sample rates", err, audio.ErrDifferentSampleRates)
+}
+
+func TestMixerMonoSum(t *testing.T) {
+	stereo := func(left, right float64) signal.Floating {
+		floats := signal.Allocator{
+			Channels: 2,
+			Length:   3,
+			Capacity: 3,
+		}.Float64()
+		signal.WriteStripedFloat64([][]float64{
+			{left, left, left},
+			{right, right, right},
+		}, floats)
+		return floats
+	}
+	mix := func(inputs ...signal.Floating) []float64 {
+		mixer := audio.Mixer{FixedDivisor: 1, MonoSum: true}
+		lines := make([]pipe.Line, 0, len(inputs)+1)
+		for _, input := range inputs {
+			lines = append(lines, pipe.Line{
+				Source: audio.Source(44100, input),
+				Sink:   mixer.Sink(),
+			})
+		}
+		sink := mock.Sink{}
+		lines = append(lines, pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		})
+		p, err := pipe.New(2, lines...)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		assertEqual(t, "channels", sink.Values.Channels(), 1)
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		return result
+	}
+	assertEqual(t, "two inputs", mix(stereo(0.5, 0.25), stereo(0.25, 0)), []float64{0.5, 0.5, 0.5})
+	assertEqual(t, "single input", mix(stereo(0.5, 0.25)), []float64{0.375, 0.375, 0.375})
+}
+
+func TestMixerTail(t *testing.T) {
+	tail := func(inputBuffer int) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			mixer := audio.Mixer{InputBuffer: inputBuffer}
+			sink := mock.Sink{}
+			p, err := pipe.New(4,
+				pipe.Line{
+					Source: (&mock.Source{
+						Limit:    5,
+						Channels: 1,
+						Value:    0.5,
+					}).Source(),
+					Sink: mixer.Sink(),
+				},
+				pipe.Line{
+					Source: (&mock.Source{
+						Limit:    11,
+						Channels: 1,
+						Value:    0.25,
+					}).Source(),
+					Sink: mixer.Sink(),
+				},
+				pipe.Line{
+					Source: mixer.Source(),
+					Sink:   sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			expected := []float64{0.375, 0.375, 0.375, 0.375, 0.375, 0.25, 0.25, 0.25, 0.25, 0.25, 0.25}
+			assertEqual(t, "result", result, expected)
+		}
+	}
+	t.Run("lockstep", tail(1))
+	t.Run("buffered", tail(3))
+}
+
+func TestMixerProduced(t *testing.T) {
+	mixer := &audio.Mixer{}
+	run := func(limit int) {
+		p, err := pipe.New(4,
+			pipe.Line{
+				Source: (&mock.Source{
+					Limit:    limit,
+					Channels: 2,
+				}).Source(),
+				Sink: mixer.Sink(),
+			},
+			pipe.Line{
+				Source: (&mock.Source{
+					Limit:    limit / 2,
+					Channels: 2,
+				}).Source(),
+				Sink: mixer.Sink(),
+			},
+			pipe.Line{
+				Source: mixer.Source(),
+				Sink:   (&mock.Sink{Discard: true}).Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	}
+	run(10)
+	assertEqual(t, "first run", mixer.Produced(), int64(10))
+	run(6)
+	assertEqual(t, "second run", mixer.Produced(), int64(6))
+}
+
+func TestMixerSingleInput(t *testing.T) {
+	const length = 1000
+	mix := func(inputs int, gain float64) []float64 {
+		mixer := audio.Mixer{MasterGain: gain}
+		var lines []pipe.Line
+		for i := 0; i < inputs; i++ {
+			lines = append(lines, pipe.Line{
+				Source: audio.Take(audio.SourceSine(44100, 440, 0.7, 2), length),
+				Sink:   mixer.Sink(),
+			})
+		}
+		sink := &mock.Sink{}
+		lines = append(lines, pipe.Line{
+			Source: mixer.Source(),
+			Sink:   sink.Sink(),
+		})
+		p, err := pipe.New(64, lines...)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		return result
+	}
+	// identical inputs are averaged without rounding errors.
+	assertEqual(t, "unity gain", mix(1, 0), mix(2, 0))
+	assertEqual(t, "master gain", mix(1, -3), mix(2, -3))
+	assertEqual(t, "length", len(mix(1, 0)), 2*length)
+}
+
 func Test100Lines(t *testing.T) {
 	run(1, 512, 51200, 100, mutable.Immutable())
 }
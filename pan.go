@@ -0,0 +1,50 @@
+package audio
+
+import (
+	"errors"
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrNotStereo is returned when stereo processor is bound to a signal
+// with number of channels other than two.
+var ErrNotStereo = errors.New("signal is not stereo")
+
+// Pan provides stereo panning processor. Position ranges from -1 (full
+// left) to 1 (full right), values beyond the range are clipped. Constant
+// power law is applied, so the perceived loudness doesn't change when
+// signal is moved across the stereo field.
+func Pan(position float64) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		if props.Channels != 2 {
+			return pipe.Processor{}, ErrNotStereo
+		}
+		left, right := panGains(position)
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					l, r := in.BufferIndex(0, i), in.BufferIndex(1, i)
+					out.SetSample(l, in.Sample(l)*left)
+					out.SetSample(r, in.Sample(r)*right)
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
+
+// panGains returns left and right channel gains for the pan position.
+func panGains(position float64) (float64, float64) {
+	switch {
+	case position < -1:
+		position = -1
+	case position > 1:
+		position = 1
+	}
+	angle := (position + 1) * math.Pi / 4
+	return math.Cos(angle), math.Sin(angle)
+}
@@ -0,0 +1,56 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestPan(t *testing.T) {
+	pan := func(position float64, expected [][]float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source: (&mock.Source{
+						Channels: 2,
+						Limit:    3,
+						Value:    1,
+					}).Source(),
+					Processors: pipe.Processors(audio.Pan(position)),
+					Sink:       sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			err = pipe.Wait(p.Start(context.Background()))
+			assertNil(t, "error", err)
+
+			result := [][]float64{make([]float64, 3), make([]float64, 3)}
+			signal.ReadStripedFloat64(sink.Values, result)
+			for c := range expected {
+				for i := range expected[c] {
+					assertEqual(t, "sample", math.Abs(result[c][i]-expected[c][i]) < 1e-9, true)
+				}
+			}
+		}
+	}
+	t.Run("full left", pan(-1, [][]float64{{1, 1, 1}, {0, 0, 0}}))
+	t.Run("full right", pan(1, [][]float64{{0, 0, 0}, {1, 1, 1}}))
+	t.Run("center", pan(0, [][]float64{
+		{math.Sqrt2 / 2, math.Sqrt2 / 2, math.Sqrt2 / 2},
+		{math.Sqrt2 / 2, math.Sqrt2 / 2, math.Sqrt2 / 2},
+	}))
+	t.Run("beyond range", pan(-2, [][]float64{{1, 1, 1}, {0, 0, 0}}))
+}
+
+func TestPanNotStereo(t *testing.T) {
+	_, err := audio.Pan(0)(mutable.Mutable(), 2, pipe.SignalProperties{Channels: 1})
+	assertEqual(t, "error", err, audio.ErrNotStereo)
+}
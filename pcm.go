@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"errors"
+	"io"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrUnsupportedBitDepth is returned when raw PCM is allocated with bit
+// depth that isn't a positive multiple of 8.
+var ErrUnsupportedBitDepth = errors.New("unsupported raw pcm bit depth")
+
+// ErrInvalidChannels is returned when raw PCM source is allocated with
+// non-positive number of channels.
+var ErrInvalidChannels = errors.New("number of channels must be positive")
+
+// PCMLayout defines the order of channel samples in raw PCM.
+type PCMLayout int
+
+const (
+	// Interleaved layout stores samples of all channels frame by frame.
+	Interleaved PCMLayout = iota
+	// Planar layout stores every buffer as consecutive blocks of samples,
+	// one block per channel. Since blocks have the buffer length, the
+	// same buffer size must be used to read and write planar PCM.
+	Planar
+)
+
+// index returns the position of the channel sample in the raw layout of
+// the buffer with provided number of frames.
+func (l PCMLayout) index(channels, frames, channel, frame int) int {
+	if l == Planar {
+		return channel*frames + frame
+	}
+	return frame*channels + channel
+}
+
+// SourceRawPCM implements signal source that reads raw PCM from the
+// reader. Samples are signed little-endian integers of the bit depth with
+// channels in the provided layout. If the reader ends in the middle of
+// the buffer, the final buffer is partial and incomplete trailing frame
+// is dropped.
+func SourceRawPCM(r io.Reader, sr signal.Frequency, channels int, bitDepth signal.BitDepth, layout PCMLayout) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		if bitDepth == 0 || bitDepth%8 != 0 {
+			return pipe.Source{}, ErrUnsupportedBitDepth
+		}
+		if channels <= 0 {
+			return pipe.Source{}, ErrInvalidChannels
+		}
+		sampleBytes := int(bitDepth / 8)
+		frameBytes := sampleBytes * channels
+		bytes := make([]byte, bufferSize*frameBytes)
+		ints := signal.Allocator{
+			Channels: channels,
+			Length:   bufferSize,
+			Capacity: bufferSize,
+		}.Int64(bitDepth)
+		return pipe.Source{
+			SourceFunc: func(out signal.Floating) (int, error) {
+				n, err := io.ReadFull(r, bytes[:out.Length()*frameBytes])
+				if err != nil && err != io.ErrUnexpectedEOF {
+					return 0, err
+				}
+				frames := n / frameBytes
+				if frames == 0 {
+					return 0, io.EOF
+				}
+				for c := 0; c < channels; c++ {
+					for i := 0; i < frames; i++ {
+						pos := layout.index(channels, frames, c, i) * sampleBytes
+						ints.SetSample(ints.BufferIndex(c, i), decodeSample(bytes[pos:pos+sampleBytes]))
+					}
+				}
+				return signal.SignedAsFloating(ints.Slice(0, frames), out), nil
+			},
+			SignalProperties: pipe.SignalProperties{
+				Channels:   channels,
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+// SinkRawPCM implements signal sink that writes raw PCM to the writer.
+// Samples are converted into signed little-endian integers of the bit
+// depth with channels in the provided layout. Floating values beyond
+// [-1, 1] are clipped.
+func SinkRawPCM(w io.Writer, bitDepth signal.BitDepth, layout PCMLayout) pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		if bitDepth == 0 || bitDepth%8 != 0 {
+			return pipe.Sink{}, ErrUnsupportedBitDepth
+		}
+		sampleBytes := int(bitDepth / 8)
+		bytes := make([]byte, bufferSize*props.Channels*sampleBytes)
+		ints := signal.Allocator{
+			Channels: props.Channels,
+			Length:   bufferSize,
+			Capacity: bufferSize,
+		}.Int64(bitDepth)
+		return pipe.Sink{
+			SinkFunc: func(in signal.Floating) error {
+				frames := signal.FloatingAsSigned(in, ints)
+				for c := 0; c < props.Channels; c++ {
+					for i := 0; i < frames; i++ {
+						pos := layout.index(props.Channels, frames, c, i) * sampleBytes
+						encodeSample(ints.Sample(ints.BufferIndex(c, i)), bytes[pos:pos+sampleBytes])
+					}
+				}
+				_, err := w.Write(bytes[:frames*props.Channels*sampleBytes])
+				return err
+			},
+		}, nil
+	}
+}
+
+// encodeSample puts the value into little-endian bytes.
+func encodeSample(v int64, b []byte) {
+	for i := range b {
+		b[i] = byte(v >> (8 * uint(i)))
+	}
+}
+
+// decodeSample converts little-endian bytes into the sign-extended value.
+func decodeSample(b []byte) int64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	shift := 64 - 8*uint(len(b))
+	return int64(v<<shift) >> shift
+}
@@ -0,0 +1,171 @@
+package audio_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestSourceRawPCM(t *testing.T) {
+	source := func(data []byte, channels int, bitDepth signal.BitDepth, expected []float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source: audio.SourceRawPCM(bytes.NewReader(data), 44100, channels, bitDepth, audio.Interleaved),
+					Sink:   sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+			assertEqual(t, "messages", sink.Messages, (len(expected)/channels+1)/2)
+		}
+	}
+	t.Run("16 bit stereo", source(
+		[]byte{
+			0xff, 0x7f, 0x00, 0x80,
+			0x00, 0x00, 0x00, 0xc0,
+			0x00, 0xc0, 0xff, 0x7f,
+		},
+		2,
+		signal.BitDepth16,
+		[]float64{1, -1, 0, -0.5, -0.5, 1},
+	))
+	t.Run("24 bit mono", source(
+		[]byte{
+			0x00, 0x00, 0xc0,
+			0xff, 0xff, 0x7f,
+			0x00, 0x00, 0x80,
+		},
+		1,
+		signal.BitDepth24,
+		[]float64{-0.5, 1, -1},
+	))
+	t.Run("incomplete frame", source(
+		[]byte{
+			0x00, 0xc0, 0xff, 0x7f,
+			0x00, 0x80,
+		},
+		2,
+		signal.BitDepth16,
+		[]float64{-0.5, 1},
+	))
+}
+
+type errorReader struct{}
+
+var errRead = errors.New("read error")
+
+func (errorReader) Read([]byte) (int, error) {
+	return 0, errRead
+}
+
+func TestSourceRawPCMErrors(t *testing.T) {
+	_, err := audio.SourceRawPCM(&bytes.Buffer{}, 44100, 1, 12, audio.Interleaved)(mutable.Mutable(), 512)
+	assertEqual(t, "bit depth error", err, audio.ErrUnsupportedBitDepth)
+	_, err = audio.SourceRawPCM(&bytes.Buffer{}, 44100, 0, signal.BitDepth16, audio.Interleaved)(mutable.Mutable(), 512)
+	assertEqual(t, "channels error", err, audio.ErrInvalidChannels)
+
+	source, err := audio.SourceRawPCM(errorReader{}, 44100, 1, signal.BitDepth16, audio.Interleaved)(mutable.Mutable(), 512)
+	assertNil(t, "allocator error", err)
+	out := signal.Allocator{Channels: 1, Length: 512, Capacity: 512}.Float64()
+	_, err = source.SourceFunc(out)
+	assertEqual(t, "read error", err, errRead)
+}
+
+func TestSinkRawPCM(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   3,
+		Capacity: 3,
+	}.Float64()
+	signal.WriteFloat64([]float64{1, -1, 0, -0.5, 2, -2}, floats)
+
+	var buf bytes.Buffer
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink:   audio.SinkRawPCM(&buf, signal.BitDepth16, audio.Interleaved),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "result", buf.Bytes(), []byte{
+		0xff, 0x7f, 0x00, 0x80,
+		0x00, 0x00, 0x00, 0xc0,
+		// clipped.
+		0xff, 0x7f, 0x00, 0x80,
+	})
+
+	_, err = audio.SinkRawPCM(&buf, 0, audio.Interleaved)(mutable.Mutable(), 512, pipe.SignalProperties{Channels: 1})
+	assertEqual(t, "bit depth error", err, audio.ErrUnsupportedBitDepth)
+}
+
+func TestRawPCMRoundTrip(t *testing.T) {
+	values := [][]float64{
+		{0.5, -0.25, 1, -1, 0.125},
+		{-0.5, 0.25, -1, 0, 0.75},
+	}
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}.Float64()
+	signal.WriteStripedFloat64(values, floats)
+
+	roundTrip := func(layout audio.PCMLayout, raw []byte) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			var buf bytes.Buffer
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source: audio.Source(44100, floats),
+					Sink:   audio.SinkRawPCM(&buf, signal.BitDepth16, layout),
+				},
+			)
+			assertNil(t, "sink error", err)
+			assertNil(t, "sink error", pipe.Wait(p.Start(context.Background())))
+			assertEqual(t, "raw", buf.Bytes(), raw)
+
+			sink := &mock.Sink{}
+			p, err = pipe.New(2,
+				pipe.Line{
+					Source: audio.SourceRawPCM(&buf, 44100, 2, signal.BitDepth16, layout),
+					Sink:   sink.Sink(),
+				},
+			)
+			assertNil(t, "source error", err)
+			assertNil(t, "source error", pipe.Wait(p.Start(context.Background())))
+			result := [][]float64{make([]float64, 5), make([]float64, 5)}
+			signal.ReadStripedFloat64(sink.Values, result)
+			for c := range values {
+				for i := range values[c] {
+					assertEqual(t, "sample", math.Abs(result[c][i]-values[c][i]) < 1e-4, true)
+				}
+			}
+		}
+	}
+	t.Run("interleaved", roundTrip(audio.Interleaved, []byte{
+		0xff, 0x3f, 0x00, 0xc0, 0x00, 0xe0, 0xff, 0x1f,
+		0xff, 0x7f, 0x00, 0x80, 0x00, 0x80, 0x00, 0x00,
+		0xff, 0x0f, 0xff, 0x5f,
+	}))
+	t.Run("planar", roundTrip(audio.Planar, []byte{
+		0xff, 0x3f, 0x00, 0xe0, 0x00, 0xc0, 0xff, 0x1f,
+		0xff, 0x7f, 0x00, 0x80, 0x00, 0x80, 0x00, 0x00,
+		0xff, 0x0f, 0xff, 0x5f,
+	}))
+}
@@ -0,0 +1,22 @@
+package audio
+
+import (
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// InvertPhase provides processor that negates every sample of the signal.
+func InvertPhase() pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Len(); i++ {
+					out.SetSample(i, -in.Sample(i))
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
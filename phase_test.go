@@ -0,0 +1,48 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestInvertPhase(t *testing.T) {
+	values := []float64{0.1, -0.2, 0.3, -0.4, 1, -1, 0.123456789}
+	floats := signal.Allocator{
+		Channels: 1,
+		Length:   len(values),
+		Capacity: len(values),
+	}.Float64()
+	signal.WriteFloat64(values, floats)
+
+	invert := func(processors []pipe.ProcessorAllocatorFunc, expected []float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, _ := pipe.New(2,
+				pipe.Line{
+					Source:     audio.Source(44100, floats),
+					Processors: processors,
+					Sink:       sink.Sink(),
+				},
+			)
+			_ = pipe.Wait(p.Start(context.Background()))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+		}
+	}
+	t.Run("once", invert(
+		pipe.Processors(audio.InvertPhase()),
+		[]float64{-0.1, 0.2, -0.3, 0.4, -1, 1, -0.123456789},
+	))
+	t.Run("twice", invert(
+		pipe.Processors(audio.InvertPhase(), audio.InvertPhase()),
+		values,
+	))
+}
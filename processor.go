@@ -0,0 +1,28 @@
+package audio
+
+import (
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ProcessorFunc provides processor that applies the function to every
+// sample of the signal. The function receives the channel index of the
+// sample, so channels can be processed differently. Signal properties
+// are not changed.
+func ProcessorFunc(fn func(channel int, sample float64) float64) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for c := 0; c < in.Channels(); c++ {
+					for i := 0; i < in.Length(); i++ {
+						idx := in.BufferIndex(c, i)
+						out.SetSample(idx, fn(c, in.Sample(idx)))
+					}
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
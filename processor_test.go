@@ -0,0 +1,48 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestProcessorFunc(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   3,
+		Capacity: 3,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{0.1, 0.2, 0.3},
+		{0.4, 0.5, 0.6},
+	}, floats)
+
+	sink := &mock.Sink{}
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Processors: pipe.Processors(audio.ProcessorFunc(func(channel int, sample float64) float64 {
+				if channel == 0 {
+					return -sample
+				}
+				return sample * 2
+			})),
+			Sink: sink.Sink(),
+		},
+	)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([][]float64, 2)
+	for i := range result {
+		result[i] = make([]float64, sink.Values.Length())
+	}
+	signal.ReadStripedFloat64(sink.Values, result)
+	assertEqual(t, "result", result, [][]float64{
+		{-0.1, -0.2, -0.3},
+		{0.8, 1, 1.2},
+	})
+}
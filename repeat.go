@@ -2,6 +2,7 @@ package audio
 
 import (
 	"context"
+	"errors"
 	"io"
 	"sync"
 	"sync/atomic"
@@ -11,14 +12,50 @@ import (
 	"pipelined.dev/signal"
 )
 
+// ErrRepeaterFlushed is returned when output is added to the repeater
+// after its sink was flushed.
+var ErrRepeaterFlushed = errors.New("repeater is flushed")
+
+// OverflowPolicy defines how repeater handles outputs that can't keep up
+// with the sink.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the sink until slow output consumes the
+	// buffer.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued buffer of slow output
+	// to make room for the new one.
+	OverflowDropOldest
+)
+
+// default size of repeater output queue.
+const defaultOutputBuffer = 1
+
 // Repeater sinks the signal and sources it to multiple pipelines.
 type Repeater struct {
+	// OutputBuffer is the number of buffers queued per output. Default
+	// is 1. Must be set before the first output is allocated.
+	OutputBuffer int
+	// Overflow is applied when the output queue is full. Default is
+	// OverflowBlock.
+	Overflow OverflowPolicy
+
 	m          sync.Mutex
 	mut        mutable.Context
 	bufferSize int
 	sampleRate signal.Frequency
 	channels   int
-	sources    []chan *message
+	sources    []*repeaterOutput
+	flushed    bool
+}
+
+// repeaterOutput is a queue of messages for a single source. Done is
+// closed when the source line ends, so the sink doesn't block on the
+// abandoned output.
+type repeaterOutput struct {
+	messages chan *message
+	done     chan struct{}
 }
 
 type message struct {
@@ -29,6 +66,9 @@ type message struct {
 // Sink must be called once per repeater.
 func (r *Repeater) Sink() pipe.SinkAllocatorFunc {
 	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		r.m.Lock()
+		r.flushed = false
+		r.m.Unlock()
 		r.sampleRate = props.SampleRate
 		r.channels = props.Channels
 		r.bufferSize = bufferSize
@@ -38,12 +78,15 @@ func (r *Repeater) Sink() pipe.SinkAllocatorFunc {
 				r.m.Lock()
 				defer r.m.Unlock()
 				out := p.Float64()
-				signal.FloatingAsFloating(in, out)
+				if n := signal.FloatingAsFloating(in, out); n != bufferSize {
+					out = out.Slice(0, n)
+				}
+				msg := &message{
+					sources: int32(len(r.sources)),
+					buffer:  out,
+				}
 				for _, source := range r.sources {
-					source <- &message{
-						sources: int32(len(r.sources)),
-						buffer:  out,
-					}
+					r.send(source, msg, p)
 				}
 				return nil
 			},
@@ -51,22 +94,117 @@ func (r *Repeater) Sink() pipe.SinkAllocatorFunc {
 				r.m.Lock()
 				defer r.m.Unlock()
 				for i := range r.sources {
-					close(r.sources[i])
+					close(r.sources[i].messages)
 				}
 				r.sources = nil
+				r.flushed = true
 				return nil
 			},
 		}, nil
 	}
 }
 
-// Source must be called at least once per repeater.
-func (r *Repeater) Source() pipe.SourceAllocatorFunc {
+// send puts the message into the output queue with respect to the
+// overflow policy. If the output is done, the message is released.
+func (r *Repeater) send(source *repeaterOutput, msg *message, p *signal.PoolAllocator) {
+	if r.Overflow != OverflowDropOldest {
+		select {
+		case source.messages <- msg:
+		case <-source.done:
+			msg.release(p)
+		}
+		return
+	}
+	for {
+		select {
+		case source.messages <- msg:
+			return
+		case <-source.done:
+			msg.release(p)
+			return
+		default:
+		}
+		// queue is full, drop the oldest message.
+		select {
+		case dropped := <-source.messages:
+			dropped.release(p)
+		default:
+		}
+	}
+}
+
+// release decrements the number of outputs that hold the message and
+// frees the buffer when the last one is done with it.
+func (m *message) release(p *signal.PoolAllocator) {
+	if atomic.AddInt32(&m.sources, -1) == 0 {
+		free(m.buffer, p)
+	}
+}
+
+func (r *Repeater) outputBuffer() int {
+	if r.OutputBuffer > 0 {
+		return r.OutputBuffer
+	}
+	return defaultOutputBuffer
+}
+
+// QueueDepths returns the number of buffers queued for each output. It
+// allows to identify outputs that can't keep up with the sink.
+func (r *Repeater) QueueDepths() []int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	depths := make([]int, len(r.sources))
+	for i := range r.sources {
+		depths[i] = len(r.sources[i].messages)
+	}
+	return depths
+}
+
+// OutputCount returns the number of repeater outputs. It's reset to zero
+// when the sink is flushed.
+func (r *Repeater) OutputCount() int {
 	r.m.Lock()
 	defer r.m.Unlock()
-	source := make(chan *message, 1)
-	r.sources = append(r.sources, source)
+	return len(r.sources)
+}
+
+// Flushed returns true if the repeater sink is flushed. Outputs can't be
+// added to the flushed repeater.
+func (r *Repeater) Flushed() bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.flushed
+}
+
+// Source must be called at least once per repeater. Outputs are added
+// when the allocator is called, after the repeater sink and until it's
+// flushed, including while the pipe is running. Once the sink is flushed,
+// the allocator fails with ErrRepeaterFlushed until the sink is allocated
+// again. When the output line ends, e.g. due to its sink error, the
+// repeater skips the output.
+func (r *Repeater) Source() pipe.SourceAllocatorFunc {
+	return r.source(nil)
+}
+
+// SourceWithTransform provides repeater source that applies transform
+// function to every output buffer. The function is applied to the copy of
+// the signal, so other outputs aren't affected.
+func (r *Repeater) SourceWithTransform(fn func(signal.Floating)) pipe.SourceAllocatorFunc {
+	return r.source(fn)
+}
+
+func (r *Repeater) source(transform func(signal.Floating)) pipe.SourceAllocatorFunc {
 	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		r.m.Lock()
+		defer r.m.Unlock()
+		if r.flushed {
+			return pipe.Source{}, ErrRepeaterFlushed
+		}
+		source := &repeaterOutput{
+			messages: make(chan *message, r.outputBuffer()),
+			done:     make(chan struct{}),
+		}
+		r.sources = append(r.sources, source)
 		p := signal.GetPoolAllocator(r.channels, bufferSize, bufferSize)
 		var (
 			messagePtr *message
@@ -74,16 +212,21 @@ func (r *Repeater) Source() pipe.SourceAllocatorFunc {
 		)
 		return pipe.Source{
 				SourceFunc: func(b signal.Floating) (int, error) {
-					messagePtr, ok = <-source
+					messagePtr, ok = <-source.messages
 					if !ok {
 						return 0, io.EOF
 					}
 					read := signal.FloatingAsFloating(messagePtr.buffer, b)
-					if atomic.AddInt32(&messagePtr.sources, -1) == 0 {
-						messagePtr.buffer.Free(p)
+					messagePtr.release(p)
+					if transform != nil {
+						transform(b.Slice(0, read))
 					}
 					return read, nil
 				},
+				FlushFunc: func(ctx context.Context) error {
+					close(source.done)
+					return nil
+				},
 				SignalProperties: pipe.SignalProperties{
 					SampleRate: r.sampleRate,
 					Channels:   r.channels,
@@ -2,11 +2,15 @@ package audio_test
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"pipelined.dev/audio"
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
 )
 
 const bufferSize = 512
@@ -46,6 +50,49 @@ func TestPipe(t *testing.T) {
 	assertEqual(t, "samples", source.Counter.Samples, 862*bufferSize)
 }
 
+func TestRepeaterAbandonedOutput(t *testing.T) {
+	repeater := &audio.Repeater{}
+	sink1 := &mock.Sink{Discard: true}
+	sink2 := &mock.Sink{ErrorOnCall: errors.New("sink error")}
+	p1, err := pipe.New(bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    100 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   sink1.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	// the erroring output is in the other pipe, so its error doesn't
+	// cancel the repeater sink.
+	p2, err := pipe.New(bufferSize,
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   sink2.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+
+	errc2 := p2.Start(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- pipe.Wait(p1.Start(context.Background()))
+	}()
+	select {
+	case err := <-done:
+		assertNil(t, "error", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("repeater is blocked by abandoned output")
+	}
+	assertEqual(t, "output error", pipe.Wait(errc2) != nil, true)
+	assertEqual(t, "samples", sink1.Counter.Samples, 100*bufferSize)
+}
+
 func TestRepeaterAddOutput(t *testing.T) {
 	repeater := &audio.Repeater{}
 	sink1 := &mock.Sink{}
@@ -79,6 +126,191 @@ func TestRepeaterAddOutput(t *testing.T) {
 	assertEqual(t, "sink2 samples", sink2.Counter.Samples > 0, true)
 }
 
+func TestRepeaterDropOldest(t *testing.T) {
+	repeater := &audio.Repeater{
+		OutputBuffer: 4,
+		Overflow:     audio.OverflowDropOldest,
+	}
+	source := &mock.Source{
+		Limit:    10 * bufferSize,
+		Channels: 2,
+	}
+	sink1 := &mock.Sink{}
+	sink2 := &mock.Sink{}
+
+	p, err := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: source.Source(),
+			Sink:   repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   sink1.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   sink2.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+
+	err = pipe.Wait(p.Start(context.Background()))
+	assertNil(t, "error", err)
+	assertEqual(t, "source messages", source.Counter.Messages, 10)
+	assertEqual(t, "sink1 messages", sink1.Counter.Messages > 0 && sink1.Counter.Messages <= 10, true)
+	assertEqual(t, "sink2 messages", sink2.Counter.Messages > 0 && sink2.Counter.Messages <= 10, true)
+}
+
+func TestRepeaterQueueDepths(t *testing.T) {
+	repeater := &audio.Repeater{}
+	source := &mock.Source{
+		Limit:    10 * bufferSize,
+		Channels: 2,
+	}
+	p, err := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: source.Source(),
+			Sink:   repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertEqual(t, "depths before start", repeater.QueueDepths(), []int{0, 0})
+
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "depths after flush", repeater.QueueDepths(), []int{})
+}
+
+func TestRepeaterOutputCount(t *testing.T) {
+	repeater := &audio.Repeater{}
+	assertEqual(t, "count before source", repeater.OutputCount(), 0)
+	p, _ := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    10 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+	)
+	assertEqual(t, "count before start", repeater.OutputCount(), 2)
+	assertEqual(t, "flushed before start", repeater.Flushed(), false)
+
+	_ = pipe.Wait(p.Start(context.Background()))
+	assertEqual(t, "count after flush", repeater.OutputCount(), 0)
+	assertEqual(t, "flushed after flush", repeater.Flushed(), true)
+}
+
+func TestRepeaterSourceWithTransform(t *testing.T) {
+	repeater := &audio.Repeater{}
+	gain := func(floats signal.Floating) {
+		for i := 0; i < floats.Len(); i++ {
+			floats.SetSample(i, floats.Sample(i)*0.5)
+		}
+	}
+	sink1 := &mock.Sink{}
+	sink2 := &mock.Sink{}
+	p, err := pipe.New(
+		2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    3,
+				Channels: 1,
+				Value:    0.8,
+			}).Source(),
+			Sink: repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   sink1.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.SourceWithTransform(gain),
+			Sink:   sink2.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result1 := make([]float64, sink1.Values.Len())
+	signal.ReadFloat64(sink1.Values, result1)
+	assertEqual(t, "untransformed", result1, []float64{0.8, 0.8, 0.8})
+	result2 := make([]float64, sink2.Values.Len())
+	signal.ReadFloat64(sink2.Values, result2)
+	assertEqual(t, "transformed", result2, []float64{0.4, 0.4, 0.4})
+}
+
+func TestRepeaterSourceAfterFlush(t *testing.T) {
+	repeater := &audio.Repeater{}
+	p, err := pipe.New(
+		bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    10 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: repeater.Sink(),
+		},
+		pipe.Line{
+			Source: repeater.Source(),
+			Sink:   (&mock.Sink{Discard: true}).Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	_, err = repeater.Source()(mutable.Mutable(), bufferSize)
+	assertEqual(t, "error", err, audio.ErrRepeaterFlushed)
+}
+
+func TestRepeaterReuse(t *testing.T) {
+	repeater := &audio.Repeater{}
+	// the same allocator is used in both runs.
+	output := repeater.Source()
+	run := func() int {
+		sink := &mock.Sink{}
+		p, err := pipe.New(
+			bufferSize,
+			pipe.Line{
+				Source: (&mock.Source{
+					Limit:    10 * bufferSize,
+					Channels: 2,
+				}).Source(),
+				Sink: repeater.Sink(),
+			},
+			pipe.Line{
+				Source: output,
+				Sink:   sink.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		return sink.Counter.Samples
+	}
+	assertEqual(t, "first run", run(), 10*bufferSize)
+	// unused allocator doesn't add output.
+	_ = repeater.Source()
+	assertEqual(t, "second run", run(), 10*bufferSize)
+}
+
 // This benchmark runs the following pipe:
 // 1 Source is repeated to 2 Sinks
 func BenchmarkRepeat(b *testing.B) {
@@ -107,6 +339,30 @@ func BenchmarkRepeat(b *testing.B) {
 	}
 }
 
+func BenchmarkRepeaterFanOut(b *testing.B) {
+	const outputs = 32
+	for i := 0; i < b.N; i++ {
+		repeater := &audio.Repeater{}
+		lines := []pipe.Line{
+			{
+				Source: (&mock.Source{
+					Limit:    100 * bufferSize,
+					Channels: 2,
+				}).Source(),
+				Sink: repeater.Sink(),
+			},
+		}
+		for j := 0; j < outputs; j++ {
+			lines = append(lines, pipe.Line{
+				Source: repeater.Source(),
+				Sink:   (&mock.Sink{Discard: true}).Sink(),
+			})
+		}
+		p, _ := pipe.New(bufferSize, lines...)
+		_ = pipe.Wait(p.Start(context.Background()))
+	}
+}
+
 func assertNil(t *testing.T, name string, result interface{}) {
 	t.Helper()
 	assertEqual(t, name, result, nil)
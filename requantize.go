@@ -0,0 +1,97 @@
+package audio
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// ErrInvalidBitDepth is returned when requantize processor is allocated
+// with zero bit depth.
+var ErrInvalidBitDepth = errors.New("bit depth must be positive")
+
+// DitherType defines the noise added to the signal before quantization.
+type DitherType int
+
+const (
+	// DitherNone truncates samples without any noise.
+	DitherNone DitherType = iota
+	// DitherTPDF adds triangular noise of two least significant bits
+	// peak-to-peak and rounds samples. It decorrelates the quantization
+	// error from the signal.
+	DitherTPDF
+)
+
+// Requantize provides processor that reduces the precision of the
+// floating signal to the bit depth. The output is still floating, but
+// every sample is on the grid of signed fixed-point values of the bit
+// depth.
+func Requantize(bitDepth signal.BitDepth, dither DitherType) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		if bitDepth == 0 {
+			return pipe.Processor{}, ErrInvalidBitDepth
+		}
+		msv := bitDepth.MaxSignedValue()
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Len(); i++ {
+					if dither == DitherTPDF {
+						out.SetSample(i, quantize(in.Sample(i), msv, tpdfNoise(), math.Round))
+					} else {
+						out.SetSample(i, quantize(in.Sample(i), msv, 0, math.Trunc))
+					}
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
+
+// quantize adds noise to the sample scaled to the fixed-point range and
+// rounds it. Noise is in least significant bits. Values beyond the range
+// are clipped.
+func quantize(v float64, msv int64, noise float64, round func(float64) float64) float64 {
+	q := quantizeFixed(v, msv, noise, round)
+	if q > 0 {
+		return float64(q) / float64(msv)
+	}
+	return float64(q) / (float64(msv) + 1)
+}
+
+// quantizeDither returns the signed fixed-point value of the sample. With
+// DitherTPDF the noise is added and the value is rounded, otherwise it's
+// truncated.
+func quantizeDither(v float64, msv int64, dither DitherType) int64 {
+	if dither == DitherTPDF {
+		return quantizeFixed(v, msv, tpdfNoise(), math.Round)
+	}
+	return quantizeFixed(v, msv, 0, math.Trunc)
+}
+
+// quantizeFixed returns the signed fixed-point value of the sample with
+// noise added before rounding.
+func quantizeFixed(v float64, msv int64, noise float64, round func(float64) float64) int64 {
+	max, min := float64(msv), -float64(msv)-1
+	scaled := v * max
+	if v < 0 {
+		scaled = -v * min
+	}
+	q := round(scaled + noise)
+	switch {
+	case q > max:
+		return msv
+	case q < min:
+		return -msv - 1
+	}
+	return int64(q)
+}
+
+// tpdfNoise returns triangular noise in range (-1, 1).
+func tpdfNoise() float64 {
+	return rand.Float64() - rand.Float64()
+}
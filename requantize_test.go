@@ -0,0 +1,95 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestRequantize(t *testing.T) {
+	const (
+		bitDepth = signal.BitDepth8
+		length   = 44100
+	)
+	lsb := 1 / float64(bitDepth.MaxSignedValue())
+	requantize := func(dither audio.DitherType, values []float64) []float64 {
+		floats := signal.Allocator{
+			Channels: 1,
+			Length:   len(values),
+			Capacity: len(values),
+		}.Float64()
+		signal.WriteFloat64(values, floats)
+
+		sink := &mock.Sink{}
+		p, err := pipe.New(512,
+			pipe.Line{
+				Source:     audio.Source(44100, floats),
+				Processors: pipe.Processors(audio.Requantize(bitDepth, dither)),
+				Sink:       sink.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		return result
+	}
+	// noise floor is the rms of quantization error.
+	noiseFloor := func(values, result []float64) float64 {
+		var sum float64
+		for i := range values {
+			sum += (result[i] - values[i]) * (result[i] - values[i])
+		}
+		return math.Sqrt(sum / float64(len(values)))
+	}
+
+	sine := make([]float64, length)
+	for i := range sine {
+		sine[i] = 0.1 * math.Sin(2*math.Pi*440*float64(i)/44100)
+	}
+	truncated := requantize(audio.DitherNone, sine)
+	dithered := requantize(audio.DitherTPDF, sine)
+	assertEqual(t, "truncated length", len(truncated), length)
+	assertEqual(t, "dithered length", len(dithered), length)
+	for i := range sine {
+		assertEqual(t, "truncated on grid", isOnGrid(truncated[i], bitDepth), true)
+		assertEqual(t, "dithered on grid", isOnGrid(dithered[i], bitDepth), true)
+		assertEqual(t, "truncated error", math.Abs(truncated[i]-sine[i]) < lsb, true)
+		assertEqual(t, "dithered error", math.Abs(dithered[i]-sine[i]) < 2*lsb, true)
+	}
+	assertEqual(t, "noise floor", noiseFloor(sine, dithered) < noiseFloor(sine, truncated), true)
+
+	// signal below the least significant bit is lost without dither.
+	quiet := make([]float64, length)
+	for i := range quiet {
+		quiet[i] = 0.3 * lsb
+	}
+	var mean float64
+	for _, v := range requantize(audio.DitherNone, quiet) {
+		assertEqual(t, "truncated quiet", v, 0.0)
+	}
+	for _, v := range requantize(audio.DitherTPDF, quiet) {
+		mean += v / length
+	}
+	assertEqual(t, "dithered quiet mean", math.Abs(mean-quiet[0]) < 0.05*lsb, true)
+}
+
+func TestRequantizeInvalidBitDepth(t *testing.T) {
+	_, err := audio.Requantize(0, audio.DitherNone)(mutable.Mutable(), 512, pipe.SignalProperties{Channels: 1})
+	assertEqual(t, "error", err, audio.ErrInvalidBitDepth)
+}
+
+func isOnGrid(v float64, bitDepth signal.BitDepth) bool {
+	scale := float64(bitDepth.MaxSignedValue())
+	if v < 0 {
+		scale++
+	}
+	return v*scale == math.Round(v*scale)
+}
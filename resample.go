@@ -0,0 +1,180 @@
+package audio
+
+import (
+	"io"
+	"math"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// Resample wraps the source, so it's converted to the target sample rate.
+// Both upsampling and downsampling are supported. Quality 0 uses linear
+// interpolation, higher values use band-limited windowed sinc
+// interpolation with quality number of zero crossings on each side. The
+// interpolation state is carried across buffers. Samples beyond the
+// stream boundaries are treated as silence, so the stream tail is
+// interpolated towards zero.
+func Resample(source pipe.SourceAllocatorFunc, targetRate signal.Frequency, quality int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		s, err := source(mut, bufferSize)
+		if err != nil {
+			return pipe.Source{}, err
+		}
+		inputRate := s.SignalProperties.SampleRate
+		if targetRate == inputRate {
+			return s, nil
+		}
+		s.SignalProperties.SampleRate = targetRate
+		r := newResampler(s.SourceFunc, s.SignalProperties.Channels, bufferSize, float64(inputRate)/float64(targetRate), quality)
+		s.SourceFunc = r.read
+		return s, nil
+	}
+}
+
+// resampler holds the interpolation state between buffers.
+type resampler struct {
+	source   pipe.SourceFunc
+	in       signal.Floating // input buffer
+	ended    bool            // input source returned io.EOF
+	step     float64
+	quality  int
+	scale    float64     // filter scale, step for downsampling and 1 otherwise
+	width    int         // number of input samples needed on each side
+	history  [][]float64 // input samples per channel
+	base     int         // stream index of the first history sample
+	produced int         // number of output samples produced
+}
+
+func newResampler(source pipe.SourceFunc, channels, bufferSize int, step float64, quality int) *resampler {
+	scale := math.Max(step, 1)
+	width := 1
+	if quality > 0 {
+		width = int(math.Ceil(float64(quality) * scale))
+	}
+	history := make([][]float64, channels)
+	for i := range history {
+		history[i] = make([]float64, 0, bufferSize+2*width)
+	}
+	return &resampler{
+		source: source,
+		in: signal.Allocator{
+			Channels: channels,
+			Length:   bufferSize,
+			Capacity: bufferSize,
+		}.Float64(),
+		step:    step,
+		quality: quality,
+		scale:   scale,
+		width:   width,
+		history: history,
+	}
+}
+
+func (r *resampler) read(out signal.Floating) (int, error) {
+	n := 0
+	for n < out.Length() {
+		available := r.base + len(r.history[0])
+		x := float64(r.produced) * r.step
+		if r.ended && x >= float64(available) {
+			break
+		}
+		if !r.ended && int(x)+r.width >= available {
+			if err := r.fill(); err != nil {
+				return n, err
+			}
+			continue
+		}
+		for c := range r.history {
+			out.SetSample(out.BufferIndex(c, n), r.interpolate(r.history[c], x))
+		}
+		r.produced++
+		n++
+	}
+	r.drop()
+	if n == 0 && r.ended {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// fill reads the next input buffer into the history.
+func (r *resampler) fill() error {
+	read, err := r.source(r.in)
+	if err == io.EOF {
+		r.ended = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for c := range r.history {
+		for i := 0; i < read; i++ {
+			r.history[c] = append(r.history[c], r.in.Sample(r.in.BufferIndex(c, i)))
+		}
+	}
+	return nil
+}
+
+// drop removes samples that won't be needed anymore.
+func (r *resampler) drop() {
+	drop := int(float64(r.produced)*r.step) - r.width + 1 - r.base
+	if drop > len(r.history[0]) {
+		drop = len(r.history[0])
+	}
+	if drop > 0 {
+		for c := range r.history {
+			r.history[c] = append(r.history[c][:0], r.history[c][drop:]...)
+		}
+		r.base += drop
+	}
+}
+
+// interpolate returns the value at stream position x. Samples beyond the
+// stream boundaries are treated as silence.
+func (r *resampler) interpolate(history []float64, x float64) float64 {
+	sample := func(i int) float64 {
+		if i -= r.base; i < 0 || i >= len(history) {
+			return 0
+		}
+		return history[i]
+	}
+	center := int(x)
+	if r.quality == 0 {
+		frac := x - float64(center)
+		s0 := sample(center)
+		return s0 + (sample(center+1)-s0)*frac
+	}
+
+	// filter length in input samples.
+	length := float64(r.quality) * r.scale
+	var sum, weights float64
+	for i := center - r.width + 1; i <= center+r.width; i++ {
+		d := x - float64(i)
+		if math.Abs(d) >= length {
+			continue
+		}
+		w := sinc(d/r.scale) * blackman(d, length)
+		sum += sample(i) * w
+		weights += w
+	}
+	if weights == 0 {
+		return 0
+	}
+	return sum / weights
+}
+
+// sinc is normalized sinc function.
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// blackman is Blackman window centered at zero with provided half-length.
+func blackman(x, length float64) float64 {
+	t := math.Pi * x / length
+	return 0.42 + 0.5*math.Cos(t) + 0.08*math.Cos(2*t)
+}
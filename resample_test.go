@@ -0,0 +1,82 @@
+package audio_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestResample(t *testing.T) {
+	resample := func(data []float64, inputRate, targetRate signal.Frequency, quality int, check func(*testing.T, []float64)) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			floats := signal.Allocator{
+				Channels: 1,
+				Length:   len(data),
+				Capacity: len(data),
+			}.Float64()
+			signal.WriteFloat64(data, floats)
+
+			sink := &mock.Sink{}
+			p, err := pipe.New(4,
+				pipe.Line{
+					Source: audio.Resample(audio.Source(inputRate, floats), targetRate, quality),
+					Sink:   sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			err = pipe.Wait(p.Start(context.Background()))
+			assertNil(t, "error", err)
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			check(t, result)
+		}
+	}
+	ramp := []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	t.Run("same rate", resample(ramp, 44100, 44100, 0, func(t *testing.T, result []float64) {
+		assertEqual(t, "result", result, ramp)
+	}))
+	t.Run("linear half rate", resample(ramp, 44100, 22050, 0, func(t *testing.T, result []float64) {
+		assertEqual(t, "result", result, []float64{0, 2, 4, 6, 8})
+	}))
+	t.Run("linear fractional rate", resample(ramp, 48000, 32000, 0, func(t *testing.T, result []float64) {
+		assertEqual(t, "result", result, []float64{0, 1.5, 3, 4.5, 6, 7.5, 9})
+	}))
+	t.Run("linear double rate", resample(ramp[:4], 22050, 44100, 0, func(t *testing.T, result []float64) {
+		// tail is interpolated towards silence.
+		assertEqual(t, "result", result, []float64{0, 0.5, 1, 1.5, 2, 2.5, 3, 1.5})
+	}))
+	constant := make([]float64, 100)
+	for i := range constant {
+		constant[i] = 0.5
+	}
+	t.Run("sinc downsampling", resample(constant, 48000, 44100, 8, func(t *testing.T, result []float64) {
+		assertEqual(t, "length", len(result), 92)
+		for _, v := range result[10 : len(result)-10] {
+			assertEqual(t, "value", math.Abs(v-0.5) < 1e-9, true)
+		}
+	}))
+	t.Run("sinc upsampling", resample(constant, 44100, 48000, 8, func(t *testing.T, result []float64) {
+		assertEqual(t, "length", len(result), 109)
+		for _, v := range result[10 : len(result)-10] {
+			assertEqual(t, "value", math.Abs(v-0.5) < 1e-9, true)
+		}
+	}))
+}
+
+func TestResampleProperties(t *testing.T) {
+	source, err := audio.Resample((&mock.Source{
+		Channels:   2,
+		SampleRate: 44100,
+	}).Source(), 48000, 0)(mutable.Mutable(), 2)
+	assertNil(t, "error", err)
+	assertEqual(t, "sample rate", source.SignalProperties.SampleRate, signal.Frequency(48000))
+	assertEqual(t, "channels", source.SignalProperties.Channels, 2)
+}
@@ -0,0 +1,81 @@
+package audio
+
+import (
+	"sync/atomic"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// SinkFunc provides sink that calls the function with every received
+// buffer. The buffer is reused by the pipe, so it must not be retained
+// after the function returns. Use SinkFuncCopy to retain buffers. If the
+// function returns an error, the pipe is cancelled.
+func SinkFunc(fn func(signal.Floating) error) pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		return pipe.Sink{
+			SinkFunc: fn,
+		}, nil
+	}
+}
+
+// SinkFuncCopy provides sink that calls the function with a copy of every
+// received buffer. The copy is owned by the function and can be retained.
+func SinkFuncCopy(fn func(signal.Floating) error) pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		return pipe.Sink{
+			SinkFunc: func(in signal.Floating) error {
+				out := signal.Allocator{
+					Channels: in.Channels(),
+					Length:   in.Length(),
+					Capacity: in.Length(),
+				}.Float64()
+				signal.FloatingAsFloating(in, out)
+				return fn(out)
+			},
+		}, nil
+	}
+}
+
+// DiscardSink provides sink that drops the signal. It allows to run the
+// pipe for the side effects of its source and processors, e.g. metering.
+func DiscardSink() pipe.SinkAllocatorFunc {
+	return SinkFunc(func(signal.Floating) error {
+		return nil
+	})
+}
+
+// Discard is a sink that drops the signal and counts it. Counters are
+// reset when the sink is allocated and are safe to read while the pipe
+// is running.
+type Discard struct {
+	// counters first for atomic alignment.
+	messages int64
+	samples  int64
+}
+
+// Sink provides discard sink allocator.
+func (d *Discard) Sink() pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		atomic.StoreInt64(&d.messages, 0)
+		atomic.StoreInt64(&d.samples, 0)
+		return pipe.Sink{
+			SinkFunc: func(in signal.Floating) error {
+				atomic.AddInt64(&d.messages, 1)
+				atomic.AddInt64(&d.samples, int64(in.Length()))
+				return nil
+			},
+		}, nil
+	}
+}
+
+// Messages returns the number of discarded buffers.
+func (d *Discard) Messages() int64 {
+	return atomic.LoadInt64(&d.messages)
+}
+
+// Samples returns the number of discarded samples per channel.
+func (d *Discard) Samples() int64 {
+	return atomic.LoadInt64(&d.samples)
+}
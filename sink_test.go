@@ -0,0 +1,108 @@
+package audio_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestSinkFunc(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{1, 2, 3, 4, 5},
+		{6, 7, 8, 9, 10},
+	}, floats)
+
+	var lengths []int
+	copies := signal.Allocator{Channels: 2}.Float64()
+	var retained []signal.Floating
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink: audio.SinkFunc(func(in signal.Floating) error {
+				lengths = append(lengths, in.Length())
+				return nil
+			}),
+		},
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink: audio.SinkFuncCopy(func(in signal.Floating) error {
+				retained = append(retained, in)
+				return nil
+			}),
+		},
+	)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "lengths", lengths, []int{2, 2, 1})
+
+	for _, s := range retained {
+		copies.Append(s)
+	}
+	result := make([]float64, copies.Len())
+	signal.ReadFloat64(copies, result)
+	expected := make([]float64, floats.Len())
+	signal.ReadFloat64(floats, expected)
+	assertEqual(t, "retained copies", result, expected)
+}
+
+func TestSinkFuncError(t *testing.T) {
+	errTest := errors.New("test error")
+	p, _ := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    10,
+				Channels: 1,
+			}).Source(),
+			Sink: audio.SinkFunc(func(in signal.Floating) error {
+				return errTest
+			}),
+		},
+	)
+	err := pipe.Wait(p.Start(context.Background()))
+	assertEqual(t, "error", errors.Is(err, errTest), true)
+}
+
+func TestDiscardSink(t *testing.T) {
+	var measured int
+	discard := &audio.Discard{}
+	run := func(limit int) {
+		p, err := pipe.New(4,
+			pipe.Line{
+				Source: (&mock.Source{
+					Limit:    limit,
+					Channels: 2,
+				}).Source(),
+				Processors: pipe.Processors(audio.Meter(2, func([]float64) {
+					measured++
+				})),
+				Sink: audio.DiscardSink(),
+			},
+			pipe.Line{
+				Source: (&mock.Source{
+					Limit:    limit,
+					Channels: 2,
+				}).Source(),
+				Sink: discard.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	}
+	run(10)
+	assertEqual(t, "measured", measured, 5)
+	assertEqual(t, "messages", discard.Messages(), int64(3))
+	assertEqual(t, "samples", discard.Samples(), int64(10))
+
+	run(6)
+	assertEqual(t, "messages after rerun", discard.Messages(), int64(2))
+	assertEqual(t, "samples after rerun", discard.Samples(), int64(6))
+}
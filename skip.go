@@ -0,0 +1,31 @@
+package audio
+
+import (
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// Skip provides processor that discards the first n samples per channel
+// of the stream and passes the rest through. If n falls in the middle of
+// the buffer, only the remainder of the buffer is passed. Fully skipped
+// buffers are passed empty.
+func Skip(n int) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		left := n
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				start := 0
+				if left > 0 {
+					start = left
+					if start > in.Length() {
+						start = in.Length()
+					}
+					left -= start
+				}
+				return signal.FloatingAsFloating(in.Slice(start, in.Length()), out), nil
+			},
+		}, nil
+	}
+}
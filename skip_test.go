@@ -0,0 +1,45 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestSkip(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7}
+	floats := signal.Allocator{
+		Channels: 1,
+		Length:   len(values),
+		Capacity: len(values),
+	}.Float64()
+	signal.WriteFloat64(values, floats)
+
+	skip := func(n int, expected []float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source:     audio.Source(44100, floats),
+					Processors: pipe.Processors(audio.Skip(n)),
+					Sink:       sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+		}
+	}
+	t.Run("none", skip(0, values))
+	t.Run("mid buffer", skip(3, []float64{4, 5, 6, 7}))
+	t.Run("buffer boundary", skip(4, []float64{5, 6, 7}))
+	t.Run("whole stream", skip(10, []float64{}))
+}
@@ -1,13 +1,21 @@
 package audio
 
 import (
+	"errors"
 	"io"
+	"math"
+	"math/rand"
+	"sync/atomic"
 
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mutable"
 	"pipelined.dev/signal"
 )
 
+// ErrInvalidLength is returned when source is allocated with negative
+// length.
+var ErrInvalidLength = errors.New("length must be non-negative")
+
 // Source implements signal source for any signal type.
 func Source(sr signal.Frequency, s signal.Signal) pipe.SourceAllocatorFunc {
 	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
@@ -80,3 +88,281 @@ func unsignedSource(data signal.Unsigned) pipe.SourceFunc {
 		return read, nil
 	}
 }
+
+// SourceReverse implements signal source that emits samples of any signal
+// type from the end to the start.
+func SourceReverse(sr signal.Frequency, s signal.Signal) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		return pipe.Source{
+			SourceFunc: reverseSource(s),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   s.Channels(),
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+func reverseSource(data signal.Signal) pipe.SourceFunc {
+	pos := data.Length()
+	return func(out signal.Floating) (int, error) {
+		if pos == 0 {
+			return 0, io.EOF
+		}
+		start := pos - out.Length()
+		if start < 0 {
+			start = 0
+		}
+		read := signal.AsFloating(signal.Slice(data, start, pos), out)
+		reverseFrames(out.Slice(0, read))
+		pos -= read
+		return read, nil
+	}
+}
+
+// reverseFrames reverses the order of samples in every channel of the
+// buffer.
+func reverseFrames(s signal.Floating) {
+	for i, j := 0, s.Length()-1; i < j; i, j = i+1, j-1 {
+		for c := 0; c < s.Channels(); c++ {
+			ii, jj := s.BufferIndex(c, i), s.BufferIndex(c, j)
+			vi, vj := s.Sample(ii), s.Sample(jj)
+			s.SetSample(ii, vj)
+			s.SetSample(jj, vi)
+		}
+	}
+}
+
+// SourceLoop implements signal source that repeats any signal type
+// provided number of times. If loops is 0, signal is repeated infinitely.
+func SourceLoop(sr signal.Frequency, s signal.Signal, loops int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		return pipe.Source{
+			SourceFunc: loopSource(s, loops),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   s.Channels(),
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+func loopSource(data signal.Signal, loops int) pipe.SourceFunc {
+	pos, loop := 0, 0
+	return func(out signal.Floating) (int, error) {
+		read := 0
+		for read < out.Length() && data.Length() > 0 {
+			// wrap around, so the buffer is contiguous.
+			if pos == data.Length() {
+				loop++
+				if loops > 0 && loop >= loops {
+					break
+				}
+				pos = 0
+			}
+			end := pos + out.Length() - read
+			if end > data.Length() {
+				end = data.Length()
+			}
+			n := signal.AsFloating(signal.Slice(data, pos, end), out.Slice(read, out.Length()))
+			pos += n
+			read += n
+		}
+		if read == 0 {
+			return 0, io.EOF
+		}
+		return read, nil
+	}
+}
+
+// SourceConcat implements signal source that emits signals of any type
+// one after another. All signals must have the same number of channels,
+// otherwise ErrDifferentChannels is returned.
+func SourceConcat(sr signal.Frequency, signals ...signal.Signal) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		channels := 0
+		for i, s := range signals {
+			if i == 0 {
+				channels = s.Channels()
+			} else if s.Channels() != channels {
+				return pipe.Source{}, ErrDifferentChannels
+			}
+		}
+		return pipe.Source{
+			SourceFunc: concatSource(signals),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   channels,
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+func concatSource(signals []signal.Signal) pipe.SourceFunc {
+	current, pos := 0, 0
+	return func(out signal.Floating) (int, error) {
+		read := 0
+		// continue with the next signal, so the buffer is contiguous.
+		for read < out.Length() && current < len(signals) {
+			data := signals[current]
+			if pos == data.Length() {
+				current++
+				pos = 0
+				continue
+			}
+			end := pos + out.Length() - read
+			if end > data.Length() {
+				end = data.Length()
+			}
+			n := signal.AsFloating(signal.Slice(data, pos, end), out.Slice(read, out.Length()))
+			pos += n
+			read += n
+		}
+		if read == 0 {
+			return 0, io.EOF
+		}
+		return read, nil
+	}
+}
+
+// Seeker changes the position of the seekable source. It's safe to call
+// its methods concurrently with running pipe.
+type Seeker struct {
+	// pending seek position, negative if none.
+	pending int64
+}
+
+// Seek sets the position of the source in samples. It's applied at the
+// next buffer boundary. Positions beyond the signal are clipped.
+func (s *Seeker) Seek(sample int) {
+	if sample < 0 {
+		sample = 0
+	}
+	atomic.StoreInt64(&s.pending, int64(sample))
+}
+
+// SeekSource implements signal source for any signal type, which position
+// can be changed with returned seeker while pipe is running.
+func SeekSource(sr signal.Frequency, s signal.Signal) (pipe.SourceAllocatorFunc, *Seeker) {
+	seeker := &Seeker{pending: -1}
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		return pipe.Source{
+			SourceFunc: seekSource(s, seeker),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   s.Channels(),
+				SampleRate: sr,
+			},
+		}, nil
+	}, seeker
+}
+
+func seekSource(data signal.Signal, seeker *Seeker) pipe.SourceFunc {
+	pos := 0
+	return func(out signal.Floating) (int, error) {
+		if seek := atomic.SwapInt64(&seeker.pending, -1); seek >= 0 {
+			pos = int(seek)
+			if pos > data.Length() {
+				pos = data.Length()
+			}
+		}
+		if pos == data.Length() {
+			return 0, io.EOF
+		}
+		end := pos + out.Length()
+		if end > data.Length() {
+			end = data.Length()
+		}
+		read := signal.AsFloating(signal.Slice(data, pos, end), out)
+		pos += read
+		return read, nil
+	}
+}
+
+// SourceSine implements signal source that generates continuous sine wave
+// with provided frequency in Hz and amplitude. All channels have the same
+// signal. The source never ends, so the line runs until its context is
+// done or the source is limited, e.g. with Take.
+func SourceSine(sr signal.Frequency, freq, amplitude float64, channels int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		return pipe.Source{
+			SourceFunc: sineSource(2*math.Pi*freq/float64(sr), amplitude),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   channels,
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+// sineSource carries the phase across buffers, so the wave is continuous.
+func sineSource(step, amplitude float64) pipe.SourceFunc {
+	phase := 0.0
+	return func(out signal.Floating) (int, error) {
+		for i := 0; i < out.Length(); i++ {
+			v := amplitude * math.Sin(phase)
+			for c := 0; c < out.Channels(); c++ {
+				out.SetSample(out.BufferIndex(c, i), v)
+			}
+			if phase += step; phase >= 2*math.Pi {
+				phase -= 2 * math.Pi
+			}
+		}
+		return out.Length(), nil
+	}
+}
+
+// SourceSilence implements signal source that generates provided number
+// of silent samples per channel. Length must be non-negative.
+func SourceSilence(sr signal.Frequency, channels, length int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		if length < 0 {
+			return pipe.Source{}, ErrInvalidLength
+		}
+		return pipe.Source{
+			SourceFunc: silenceSource(length),
+			SignalProperties: pipe.SignalProperties{
+				Channels:   channels,
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
+
+func silenceSource(length int) pipe.SourceFunc {
+	pos := 0
+	return func(out signal.Floating) (int, error) {
+		if pos == length {
+			return 0, io.EOF
+		}
+		read := out.Length()
+		if read > length-pos {
+			read = length - pos
+		}
+		for i := 0; i < read*out.Channels(); i++ {
+			out.SetSample(i, 0)
+		}
+		pos += read
+		return read, nil
+	}
+}
+
+// SourceWhiteNoise implements signal source that generates uniform white
+// noise within [-amplitude, amplitude]. Every channel has independent
+// noise. The source never ends, so the line runs until its context is
+// done or the source is limited, e.g. with Take.
+func SourceWhiteNoise(sr signal.Frequency, amplitude float64, channels int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		return pipe.Source{
+			SourceFunc: func(out signal.Floating) (int, error) {
+				for i := 0; i < out.Len(); i++ {
+					out.SetSample(i, amplitude*(2*rand.Float64()-1))
+				}
+				return out.Length(), nil
+			},
+			SignalProperties: pipe.SignalProperties{
+				Channels:   channels,
+				SampleRate: sr,
+			},
+		}, nil
+	}
+}
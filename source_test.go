@@ -8,6 +8,7 @@ import (
 	"pipelined.dev/audio"
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
 	"pipelined.dev/signal"
 )
 
@@ -66,3 +67,305 @@ func TestSource(t *testing.T) {
 	}
 
 }
+
+func TestSourceReverse(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 2,
+		Length:   5,
+		Capacity: 5,
+	}
+	floats := alloc.Float64()
+	signal.WriteStripedFloat64([][]float64{{-1, -0.5, 0, 0.5, 1}, {1, 0.5, 0, -0.5, -1}}, floats)
+	ints := alloc.Int64(signal.MaxBitDepth)
+	signal.WriteStripedInt64([][]int64{{math.MinInt64, 0, 0, 0, math.MaxInt64}, {math.MaxInt64, 0, 0, 0, math.MinInt64}}, ints)
+	uints := alloc.Uint64(signal.MaxBitDepth)
+	signal.WriteStripedUint64([][]uint64{{0, math.MaxInt64 + 1, math.MaxInt64 + 1, math.MaxInt64 + 1, math.MaxUint64}, {math.MaxUint64, math.MaxInt64 + 1, math.MaxInt64 + 1, math.MaxInt64 + 1, 0}}, uints)
+
+	sampleRate := signal.Frequency(44100)
+	tests := []struct {
+		source   pipe.SourceAllocatorFunc
+		expected [][]float64
+		msg      string
+	}{
+		{
+			source:   audio.SourceReverse(sampleRate, floats),
+			expected: [][]float64{{1, 0.5, 0, -0.5, -1}, {-1, -0.5, 0, 0.5, 1}},
+			msg:      "Floats reversed",
+		},
+		{
+			source:   audio.SourceReverse(sampleRate, ints),
+			expected: [][]float64{{1, 0, 0, 0, -1}, {-1, 0, 0, 0, 1}},
+			msg:      "Ints reversed",
+		},
+		{
+			source:   audio.SourceReverse(sampleRate, uints),
+			expected: [][]float64{{1, 0, 0, 0, -1}, {-1, 0, 0, 0, 1}},
+			msg:      "Uints reversed",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		sink := mock.Sink{}
+
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: test.source,
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := [][]float64{make([]float64, 5), make([]float64, 5)}
+		signal.ReadStripedFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestSourceLoop(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Length:   3,
+		Capacity: 3,
+	}
+	floats := alloc.Float64()
+	signal.WriteFloat64([]float64{-1, 0, 1}, floats)
+	ints := alloc.Int64(signal.MaxBitDepth)
+	signal.WriteInt64([]int64{math.MinInt64, 0, math.MaxInt64}, ints)
+
+	sampleRate := signal.Frequency(44100)
+	tests := []struct {
+		source   pipe.SourceAllocatorFunc
+		expected []float64
+		msg      string
+	}{
+		{
+			source:   audio.SourceLoop(sampleRate, floats, 1),
+			expected: []float64{-1, 0, 1},
+			msg:      "Floats single loop",
+		},
+		{
+			source:   audio.SourceLoop(sampleRate, floats, 3),
+			expected: []float64{-1, 0, 1, -1, 0, 1, -1, 0, 1},
+			msg:      "Floats three loops",
+		},
+		{
+			source:   audio.SourceLoop(sampleRate, ints, 2),
+			expected: []float64{-1, 0, 1, -1, 0, 1},
+			msg:      "Ints two loops",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		sink := mock.Sink{}
+
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: test.source,
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+	}
+
+	t.Run("infinite loop", func(t *testing.T) {
+		source, _ := audio.SourceLoop(sampleRate, floats, 0)(mutable.Mutable(), bufferSize)
+		buf := signal.Allocator{
+			Channels: 1,
+			Length:   bufferSize,
+			Capacity: bufferSize,
+		}.Float64()
+		result := make([]float64, 0)
+		for i := 0; i < 4; i++ {
+			n, err := source.SourceFunc(buf)
+			assertNil(t, "error", err)
+			assertEqual(t, "read", n, bufferSize)
+			values := make([]float64, bufferSize)
+			signal.ReadFloat64(buf, values)
+			result = append(result, values...)
+		}
+		assertEqual(t, "result", result, []float64{-1, 0, 1, -1, 0, 1, -1, 0})
+	})
+}
+
+func TestSourceConcat(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Length:   3,
+		Capacity: 3,
+	}
+	floats := alloc.Float64()
+	signal.WriteFloat64([]float64{-1, 0, 1}, floats)
+	ints := alloc.Int64(signal.MaxBitDepth)
+	signal.WriteInt64([]int64{math.MinInt64, 0, math.MaxInt64}, ints)
+	empty := signal.Allocator{Channels: 1}.Float64()
+
+	sampleRate := signal.Frequency(44100)
+	tests := []struct {
+		source   pipe.SourceAllocatorFunc
+		expected []float64
+		messages int
+		msg      string
+	}{
+		{
+			source:   audio.SourceConcat(sampleRate, floats),
+			expected: []float64{-1, 0, 1},
+			messages: 2,
+			msg:      "Single signal",
+		},
+		{
+			source:   audio.SourceConcat(sampleRate, floats, ints),
+			expected: []float64{-1, 0, 1, -1, 0, 1},
+			messages: 3,
+			msg:      "Floats and ints",
+		},
+		{
+			source:   audio.SourceConcat(sampleRate, floats, empty, floats),
+			expected: []float64{-1, 0, 1, -1, 0, 1},
+			messages: 3,
+			msg:      "Empty in the middle",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		sink := mock.Sink{}
+
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: test.source,
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+		assertEqual(t, test.msg+" messages", sink.Counter.Messages, test.messages)
+	}
+
+	stereo := signal.Allocator{Channels: 2, Length: 3, Capacity: 3}.Float64()
+	_, err := audio.SourceConcat(sampleRate, floats, stereo)(mutable.Mutable(), bufferSize)
+	assertEqual(t, "different channels", err, audio.ErrDifferentChannels)
+}
+
+func TestSeekSource(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 1,
+		Length:   6,
+		Capacity: 6,
+	}.Float64()
+	signal.WriteFloat64([]float64{0, 1, 2, 3, 4, 5}, floats)
+
+	bufferSize := 2
+	allocator, seeker := audio.SeekSource(44100, floats)
+	source, _ := allocator(mutable.Mutable(), bufferSize)
+	buf := signal.Allocator{
+		Channels: 1,
+		Length:   bufferSize,
+		Capacity: bufferSize,
+	}.Float64()
+	read := func() []float64 {
+		n, err := source.SourceFunc(buf)
+		if err != nil {
+			return nil
+		}
+		values := make([]float64, n)
+		signal.ReadFloat64(buf, values)
+		return values
+	}
+
+	assertEqual(t, "first", read(), []float64{0, 1})
+	seeker.Seek(3)
+	assertEqual(t, "after seek", read(), []float64{3, 4})
+	assertEqual(t, "tail", read(), []float64{5})
+	assertEqual(t, "end", read(), []float64(nil))
+	seeker.Seek(1)
+	assertEqual(t, "seek after end", read(), []float64{1, 2})
+	seeker.Seek(10)
+	assertEqual(t, "seek beyond end", read(), []float64(nil))
+}
+
+func TestSourceSine(t *testing.T) {
+	const (
+		sampleRate = 8000
+		freq       = 440
+		amplitude  = 0.5
+		length     = 100
+	)
+	sink := &mock.Sink{}
+	p, err := pipe.New(3,
+		pipe.Line{
+			Source: audio.Take(audio.SourceSine(sampleRate, freq, amplitude, 2), length),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := [][]float64{make([]float64, length), make([]float64, length)}
+	assertEqual(t, "length", signal.ReadStripedFloat64(sink.Values, result), length)
+	for i := 0; i < length; i++ {
+		expected := amplitude * math.Sin(2*math.Pi*freq*float64(i)/sampleRate)
+		assertEqual(t, "left", math.Abs(result[0][i]-expected) < 1e-9, true)
+		assertEqual(t, "right", result[1][i], result[0][i])
+	}
+}
+
+func TestSourceSilence(t *testing.T) {
+	sink := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: audio.SourceSilence(44100, 2, 5),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := make([]float64, sink.Values.Len())
+	signal.ReadFloat64(sink.Values, result)
+	assertEqual(t, "result", result, make([]float64, 10))
+	assertEqual(t, "messages", sink.Messages, 3)
+
+	_, err = audio.SourceSilence(44100, 2, -1)(mutable.Mutable(), 2)
+	assertEqual(t, "negative length", err, audio.ErrInvalidLength)
+}
+
+func TestSourceWhiteNoise(t *testing.T) {
+	const (
+		amplitude = 0.5
+		length    = 1000
+	)
+	sink := &mock.Sink{}
+	p, err := pipe.New(64,
+		pipe.Line{
+			Source: audio.Take(audio.SourceWhiteNoise(44100, amplitude, 2), length),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+	result := [][]float64{make([]float64, length), make([]float64, length)}
+	assertEqual(t, "length", signal.ReadStripedFloat64(sink.Values, result), length)
+	var equal int
+	for i := 0; i < length; i++ {
+		for c := range result {
+			assertEqual(t, "amplitude", math.Abs(result[c][i]) <= amplitude, true)
+		}
+		if result[0][i] == result[1][i] {
+			equal++
+		}
+	}
+	assertEqual(t, "independent channels", equal < length, true)
+}
@@ -0,0 +1,158 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+var (
+	// ErrChannelOutOfRange is returned when splitter output is bound to
+	// the channel that sinked signal doesn't have.
+	ErrChannelOutOfRange = errors.New("channel out of range")
+	// ErrSplitterFlushed is returned when output is added to the splitter
+	// after its sink was flushed.
+	ErrSplitterFlushed = errors.New("splitter is flushed")
+)
+
+// Splitter sinks multichannel signal and sources its channels to multiple
+// pipelines as mono signals.
+type Splitter struct {
+	m          sync.Mutex
+	sampleRate signal.Frequency
+	channels   int
+	outputs    []*splitterOutput
+	flushed    bool
+}
+
+// splitterOutput is a queue of messages for a single channel source. Done
+// is closed when the source line ends, so the sink doesn't block on the
+// abandoned output.
+type splitterOutput struct {
+	channel int
+	source  chan *message
+	done    chan struct{}
+}
+
+// Sink must be called once per splitter.
+func (s *Splitter) Sink() pipe.SinkAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Sink, error) {
+		s.m.Lock()
+		defer s.m.Unlock()
+		s.flushed = false
+		s.sampleRate = props.SampleRate
+		s.channels = props.Channels
+		p := signal.GetPoolAllocator(1, bufferSize, bufferSize)
+		// messages per channel, allocated only for bound channels.
+		messages := make([]*message, props.Channels)
+		return pipe.Sink{
+			SinkFunc: func(in signal.Floating) error {
+				s.m.Lock()
+				defer s.m.Unlock()
+				// count outputs before sending, so message isn't
+				// released while it's still distributed.
+				for _, output := range s.outputs {
+					msg := messages[output.channel]
+					if msg == nil {
+						msg = &message{buffer: deinterleave(in, output.channel, p)}
+						messages[output.channel] = msg
+					}
+					msg.sources++
+				}
+				for _, output := range s.outputs {
+					msg := messages[output.channel]
+					select {
+					case output.source <- msg:
+					case <-output.done:
+						msg.release(p)
+					}
+				}
+				for i := range messages {
+					messages[i] = nil
+				}
+				return nil
+			},
+			FlushFunc: func(ctx context.Context) error {
+				s.m.Lock()
+				defer s.m.Unlock()
+				for i := range s.outputs {
+					close(s.outputs[i].source)
+				}
+				s.outputs = nil
+				s.flushed = true
+				return nil
+			},
+		}, nil
+	}
+}
+
+// Channel provides splitter source of a single channel. It must be called
+// at least once per splitter. Outputs are added when the allocator is
+// called, after the splitter sink and until it's flushed, including while
+// the pipe is running. Once the sink is flushed, the allocator fails with
+// ErrSplitterFlushed. When the output line ends, e.g. due to its sink
+// error, the splitter skips the output.
+func (s *Splitter) Channel(c int) pipe.SourceAllocatorFunc {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if c < 0 || s.channels > 0 && c >= s.channels {
+		return func(mutable.Context, int) (pipe.Source, error) {
+			return pipe.Source{}, ErrChannelOutOfRange
+		}
+	}
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		s.m.Lock()
+		defer s.m.Unlock()
+		if s.flushed {
+			return pipe.Source{}, ErrSplitterFlushed
+		}
+		if c >= s.channels {
+			return pipe.Source{}, ErrChannelOutOfRange
+		}
+		output := &splitterOutput{
+			channel: c,
+			source:  make(chan *message, 1),
+			done:    make(chan struct{}),
+		}
+		s.outputs = append(s.outputs, output)
+		p := signal.GetPoolAllocator(1, bufferSize, bufferSize)
+		return pipe.Source{
+				SourceFunc: func(b signal.Floating) (int, error) {
+					msg, ok := <-output.source
+					if !ok {
+						return 0, io.EOF
+					}
+					read := signal.FloatingAsFloating(msg.buffer, b)
+					msg.release(p)
+					return read, nil
+				},
+				FlushFunc: func(ctx context.Context) error {
+					close(output.done)
+					return nil
+				},
+				SignalProperties: pipe.SignalProperties{
+					SampleRate: s.sampleRate,
+					Channels:   1,
+				},
+			},
+			nil
+	}
+}
+
+// deinterleave copies a single channel of the signal into a new mono
+// buffer from the pool.
+func deinterleave(in signal.Floating, channel int, p *signal.PoolAllocator) signal.Floating {
+	out := p.Float64()
+	if in.Length() != out.Length() {
+		out = out.Slice(0, in.Length())
+	}
+	for i := 0; i < in.Length(); i++ {
+		out.SetSample(i, in.Sample(in.BufferIndex(channel, i)))
+	}
+	return out
+}
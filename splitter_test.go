@@ -0,0 +1,155 @@
+package audio_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestSplitter(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 3,
+		Length:   5,
+		Capacity: 5,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{
+		{0.1, 0.2, 0.3, 0.4, 0.5},
+		{0.6, 0.7, 0.8, 0.9, 1},
+		{-0.1, -0.2, -0.3, -0.4, -0.5},
+	}, floats)
+
+	splitter := &audio.Splitter{}
+	sink0 := &mock.Sink{}
+	sink2 := &mock.Sink{}
+	sink2Copy := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: audio.Source(44100, floats),
+			Sink:   splitter.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(0),
+			Sink:   sink0.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(2),
+			Sink:   sink2.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(2),
+			Sink:   sink2Copy.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	err = pipe.Wait(p.Start(context.Background()))
+	assertNil(t, "error", err)
+
+	read := func(s signal.Floating) []float64 {
+		result := make([]float64, s.Len())
+		signal.ReadFloat64(s, result)
+		return result
+	}
+	assertEqual(t, "channel 0", read(sink0.Values), []float64{0.1, 0.2, 0.3, 0.4, 0.5})
+	assertEqual(t, "channel 2", read(sink2.Values), []float64{-0.1, -0.2, -0.3, -0.4, -0.5})
+	assertEqual(t, "channel 2 copy", read(sink2Copy.Values), []float64{-0.1, -0.2, -0.3, -0.4, -0.5})
+
+	_, err = splitter.Channel(0)(mutable.Mutable(), 2)
+	assertEqual(t, "flushed error", err, audio.ErrSplitterFlushed)
+}
+
+func TestSplitterChannelOutOfRange(t *testing.T) {
+	splitter := &audio.Splitter{}
+	_, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 2,
+				Limit:    10,
+			}).Source(),
+			Sink: splitter.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(2),
+			Sink:   (&mock.Sink{}).Sink(),
+		},
+	)
+	assertEqual(t, "error", err != nil, true)
+}
+
+func TestSplitterRejectedChannel(t *testing.T) {
+	splitter := &audio.Splitter{}
+	_, err := splitter.Channel(-1)(mutable.Mutable(), 2)
+	assertEqual(t, "negative", err, audio.ErrChannelOutOfRange)
+
+	sink := &mock.Sink{}
+	p, err := pipe.New(2,
+		pipe.Line{
+			Source: (&mock.Source{
+				Channels: 2,
+				Limit:    10,
+			}).Source(),
+			Sink: splitter.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(1),
+			Sink:   sink.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	_, err = splitter.Channel(5)(mutable.Mutable(), 2)
+	assertEqual(t, "out of range", err, audio.ErrChannelOutOfRange)
+	_, err = splitter.Channel(-1)(mutable.Mutable(), 2)
+	assertEqual(t, "negative after sink", err, audio.ErrChannelOutOfRange)
+
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "samples", sink.Counter.Samples, 10)
+}
+
+func TestSplitterAbandonedOutput(t *testing.T) {
+	splitter := &audio.Splitter{}
+	sink1 := &mock.Sink{Discard: true}
+	sink2 := &mock.Sink{ErrorOnCall: errors.New("sink error")}
+	p1, err := pipe.New(bufferSize,
+		pipe.Line{
+			Source: (&mock.Source{
+				Limit:    100 * bufferSize,
+				Channels: 2,
+			}).Source(),
+			Sink: splitter.Sink(),
+		},
+		pipe.Line{
+			Source: splitter.Channel(0),
+			Sink:   sink1.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+	// the erroring output is in the other pipe, so its error doesn't
+	// cancel the splitter sink.
+	p2, err := pipe.New(bufferSize,
+		pipe.Line{
+			Source: splitter.Channel(1),
+			Sink:   sink2.Sink(),
+		},
+	)
+	assertNil(t, "error", err)
+
+	errc2 := p2.Start(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- pipe.Wait(p1.Start(context.Background()))
+	}()
+	select {
+	case err := <-done:
+		assertNil(t, "error", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("splitter is blocked by abandoned output")
+	}
+	assertEqual(t, "output error", pipe.Wait(errc2) != nil, true)
+	assertEqual(t, "samples", sink1.Counter.Samples, 100*bufferSize)
+}
@@ -0,0 +1,36 @@
+package audio
+
+import (
+	"io"
+
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// Take wraps the source, so it reads at most n samples per channel and
+// then returns io.EOF. The buffer that crosses n is truncated. If n lands
+// on the buffer boundary, the full buffer is read and the next call
+// returns io.EOF. The wrapped source isn't read after n samples.
+func Take(source pipe.SourceAllocatorFunc, n int) pipe.SourceAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		s, err := source(mut, bufferSize)
+		if err != nil {
+			return pipe.Source{}, err
+		}
+		sourceFn := s.SourceFunc
+		taken := 0
+		s.SourceFunc = func(out signal.Floating) (int, error) {
+			if taken >= n {
+				return 0, io.EOF
+			}
+			if left := n - taken; out.Length() > left {
+				out = out.Slice(0, left)
+			}
+			read, err := sourceFn(out)
+			taken += read
+			return read, err
+		}
+		return s, nil
+	}
+}
@@ -0,0 +1,51 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/signal"
+)
+
+func TestTake(t *testing.T) {
+	take := func(n int, expected []float64, messages int) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			source := &mock.Source{
+				Channels: 2,
+				Limit:    100,
+				Value:    0.5,
+			}
+			sink := &mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source: audio.Take(source.Source(), n),
+					Sink:   sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+			assertEqual(t, "messages", sink.Messages, messages)
+			assertEqual(t, "source samples", source.Counter.Samples, len(expected)/2)
+		}
+	}
+	t.Run("crosses buffer", take(3, []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5}, 2))
+	t.Run("buffer boundary", take(4, []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5}, 2))
+	t.Run("zero", take(0, []float64{}, 0))
+	t.Run("beyond source", take(200, expectedTake(100), 50))
+}
+
+func expectedTake(samples int) []float64 {
+	values := make([]float64, samples*2)
+	for i := range values {
+		values[i] = 0.5
+	}
+	return values
+}
@@ -1,9 +1,13 @@
 package audio
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"pipelined.dev/pipe"
 	"pipelined.dev/pipe/mutable"
@@ -12,6 +16,30 @@ import (
 
 // Track is a sequence of pipes which are executed one after another.
 type Track struct {
+	// EdgeSmoothing is the number of samples faded at the start and the
+	// end of every clip to suppress clicks at hard cuts. Zero disables
+	// it.
+	EdgeSmoothing int
+	// EdgeCurve is the shape of edge smoothing fades. Default is
+	// FadeLinear.
+	EdgeCurve FadeCurve
+	// Overlap defines which clip wins when added clip overlaps existing
+	// ones. Default is KeepNew.
+	Overlap OverlapPolicy
+	// Resolver resolves overlaps of added clip with existing ones. If
+	// set, Overlap policy is ignored.
+	Resolver OverlapResolver
+	// AdaptChannels makes the track convert clips with different number
+	// of channels instead of panic. Track channel c is taken from clip
+	// channel c modulo clip channels on upmix, so mono is duplicated, and
+	// is averaged from clip channels c, c + track channels, and so on on
+	// downmix. Adapted clip is copied into a floating buffer.
+	AdaptChannels bool
+	// Progress is called by the track source after every buffer with
+	// the position of the source and the end of the rendered range. It's
+	// called in the pipe goroutine, so it must not block.
+	Progress func(pos, total int)
+
 	once     sync.Once
 	channels int
 
@@ -19,6 +47,95 @@ type Track struct {
 	tail *link
 }
 
+// OverlapPolicy defines how overlaps of clips are resolved.
+type OverlapPolicy int
+
+const (
+	// KeepNew trims and splits existing clips, so added clip is placed
+	// entirely.
+	KeepNew OverlapPolicy = iota
+	// KeepExisting trims and splits added clip, so only its parts that
+	// fit the gaps between existing clips are placed.
+	KeepExisting
+)
+
+// Clip is a signal placed at the track position.
+type Clip struct {
+	At   int
+	Data signal.Signal
+}
+
+// End position of the clip in the track.
+func (c Clip) End() int {
+	return c.At + c.Data.Length()
+}
+
+// OverlapResolver decides how the added clip is placed over existing
+// clips. It receives the added clip and existing clips it overlaps,
+// ordered by position, and returns clips that replace them on the track.
+// Returned clips must not overlap, otherwise the later ones are kept.
+type OverlapResolver interface {
+	Resolve(added Clip, overlapped []Clip) []Clip
+}
+
+// TruncateResolver trims and splits existing clips, so added clip is
+// placed entirely. It's used for KeepNew policy.
+type TruncateResolver struct{}
+
+// Resolve implements OverlapResolver.
+func (TruncateResolver) Resolve(added Clip, overlapped []Clip) []Clip {
+	var heads, tails []Clip
+	for _, c := range overlapped {
+		if c.At < added.At {
+			heads = append(heads, Clip{
+				At:   c.At,
+				Data: signal.Slice(c.Data, 0, added.At-c.At),
+			})
+		}
+		if c.End() > added.End() {
+			tails = append(tails, Clip{
+				At:   added.End(),
+				Data: signal.Slice(c.Data, added.End()-c.At, c.Data.Length()),
+			})
+		}
+	}
+	return append(append(heads, added), tails...)
+}
+
+// KeepExistingResolver trims and splits added clip, so only its parts
+// that fit the gaps between existing clips are placed. It's used for
+// KeepExisting policy.
+type KeepExistingResolver struct{}
+
+// Resolve implements OverlapResolver.
+func (KeepExistingResolver) Resolve(added Clip, overlapped []Clip) []Clip {
+	clips := make([]Clip, 0, 2*len(overlapped)+1)
+	pos := added.At
+	for _, c := range overlapped {
+		if c.At > pos {
+			clips = append(clips, Clip{
+				At:   pos,
+				Data: signal.Slice(added.Data, pos-added.At, c.At-added.At),
+			})
+		}
+		clips = append(clips, c)
+		pos = c.End()
+	}
+	if pos < added.End() {
+		clips = append(clips, Clip{
+			At:   pos,
+			Data: signal.Slice(added.Data, pos-added.At, added.Data.Length()),
+		})
+	}
+	return clips
+}
+
+// Gap is a silent region of the track between clips.
+type Gap struct {
+	From int
+	To   int
+}
+
 // stream is a sequence of Clips in track.
 // It uses double-linked list structure.
 type link struct {
@@ -36,14 +153,95 @@ func (l *link) End() int {
 	return l.at + l.data.Length()
 }
 
+// Render flattens the track into a contiguous asset. Gaps between clips
+// are rendered as silence.
+func (t *Track) Render(sampleRate signal.Frequency, bufferSize int) (*Asset, error) {
+	asset := &Asset{}
+	p, err := pipe.New(bufferSize, pipe.Line{
+		Source: t.Source(sampleRate, 0, 0),
+		Sink:   asset.Sink(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := pipe.Wait(p.Start(context.Background())); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// ReadAt reads the track samples starting at the position into the
+// buffer. Gaps are read as silence. It returns the number of samples per
+// channel written, which is less than the buffer length if the track ends
+// within the buffer.
+func (t *Track) ReadAt(out signal.Floating, at int) int {
+	end := t.endIndex()
+	if at >= end {
+		return 0
+	}
+	for i := 0; i < out.Len(); i++ {
+		out.SetSample(i, 0)
+	}
+	n, _ := trackSource(t.head.nextAfter(at), at, end, t.EdgeSmoothing, t.EdgeCurve, nil)(out)
+	return n
+}
+
+// AutomationPoint defines the gain at the track position. Gain is
+// linearly interpolated between points.
+type AutomationPoint struct {
+	At   int
+	Gain float64
+}
+
 // Source implements track source with a sequence of not overlapped clips.
 func (t *Track) Source(sampleRate signal.Frequency, start, end int) pipe.SourceAllocatorFunc {
+	return t.source(sampleRate, start, end, nil)
+}
+
+// SourceDuration implements track source bounded by time. Both start and
+// end are converted into the index of the first sample that starts at or
+// after the time, so adjacent segments neither drop nor duplicate the
+// boundary sample. Zero end means the end of the track.
+func (t *Track) SourceDuration(sampleRate signal.Frequency, start, end time.Duration) pipe.SourceAllocatorFunc {
+	return t.Source(sampleRate, sampleIndex(sampleRate, start), sampleIndex(sampleRate, end))
+}
+
+// sampleIndex returns the index of the first sample that starts at or
+// after the time. Whole seconds are converted separately to avoid
+// overflow for long durations.
+func sampleIndex(sampleRate signal.Frequency, d time.Duration) int {
+	seconds, rem := int64(d/time.Second), int64(d%time.Second)
+	return int(seconds*int64(sampleRate) + (rem*int64(sampleRate)+int64(time.Second)-1)/int64(time.Second))
+}
+
+// SourceWithAutomation implements track source with gain automation. The
+// automation spans the whole track, including gaps between clips. Before
+// the first and after the last point their gain is applied. If no points
+// provided, the gain is not changed.
+func (t *Track) SourceWithAutomation(sampleRate signal.Frequency, start, end int, points []AutomationPoint) pipe.SourceAllocatorFunc {
+	sorted := make([]AutomationPoint, len(points))
+	copy(sorted, points)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].At < sorted[j].At
+	})
+	return t.source(sampleRate, start, end, sorted)
+}
+
+func (t *Track) source(sampleRate signal.Frequency, start, end int, points []AutomationPoint) pipe.SourceAllocatorFunc {
 	if end == 0 {
 		end = t.endIndex()
 	}
 	return func(mut mutable.Context, bufferSize int) (pipe.Source, error) {
+		var a *automation
+		if len(points) > 0 {
+			a = &automation{points: points}
+		}
+		sourceFn := trackSource(t.head.nextAfter(start), start, end, t.EdgeSmoothing, t.EdgeCurve, a)
+		if t.Progress != nil {
+			sourceFn = progressSource(sourceFn, start, end, t.Progress)
+		}
 		return pipe.Source{
-				SourceFunc: trackSource(t.head.nextAfter(start), start, end),
+				SourceFunc: sourceFn,
 				SignalProperties: pipe.SignalProperties{
 					Channels:   t.channels,
 					SampleRate: sampleRate,
@@ -53,17 +251,39 @@ func (t *Track) Source(sampleRate signal.Frequency, start, end int) pipe.SourceA
 	}
 }
 
-func trackSource(current *link, start, end int) pipe.SourceFunc {
+// progressSource reports the position after every buffer read by the
+// source function.
+func progressSource(fn pipe.SourceFunc, start, end int, progress func(pos, total int)) pipe.SourceFunc {
 	pos := start
 	return func(out signal.Floating) (int, error) {
-		if current == nil {
+		read, err := fn(out)
+		if read > 0 {
+			pos += read
+			progress(pos, end)
+		}
+		return read, err
+	}
+}
+
+func trackSource(current *link, start, end, smoothing int, curve FadeCurve, a *automation) pipe.SourceFunc {
+	pos := start
+	return func(out signal.Floating) (read int, err error) {
+		if current == nil || pos >= end {
 			return 0, io.EOF
 		}
+		// don't read beyond the end.
+		if pos+out.Length() > end {
+			out = out.Slice(0, end-pos)
+		}
+		if a != nil {
+			// apply automation to the samples read from the buffer start.
+			defer func(start int) {
+				a.apply(out.Slice(0, read), start)
+			}(pos)
+		}
 
 		// track index where source buffer will end
 		bufferEnd := pos + out.Length()
-		// number of samples read per channel
-		read := 0
 		for pos < bufferEnd {
 			if current == nil {
 				return read, nil
@@ -92,6 +312,9 @@ func trackSource(current *link, start, end int) pipe.SourceFunc {
 				sliceEnd = sliceStart + out.Length() - read
 			}
 			n := signal.AsFloating(signal.Slice(current.data, sliceStart, sliceEnd), out.Slice(read, out.Length()))
+			if smoothing > 0 {
+				smoothEdges(out.Slice(read, read+n), sliceStart, current.data.Length(), smoothing, curve)
+			}
 			read += n
 			pos += n
 			if pos >= current.End() {
@@ -102,6 +325,57 @@ func trackSource(current *link, start, end int) pipe.SourceFunc {
 	}
 }
 
+// smoothEdges applies fade in and fade out to the samples of the clip
+// copied into the buffer. Offset is the clip position of the first copied
+// sample.
+func smoothEdges(out signal.Floating, offset, length, samples int, curve FadeCurve) {
+	for i := 0; i < out.Length(); i++ {
+		pos := offset + i
+		if pos >= samples && length-1-pos >= samples {
+			continue
+		}
+		gain := math.Min(fadeGain(FadeIn, curve, pos, samples), fadeGain(FadeIn, curve, length-1-pos, samples))
+		for c := 0; c < out.Channels(); c++ {
+			idx := out.BufferIndex(c, i)
+			out.SetSample(idx, out.Sample(idx)*gain)
+		}
+	}
+}
+
+// automation holds the state of track gain automation.
+type automation struct {
+	points []AutomationPoint
+	// index of the first point after the current position.
+	next int
+}
+
+// apply scales samples read into the buffer. Positions must not decrease
+// between calls.
+func (a *automation) apply(out signal.Floating, start int) {
+	for i := 0; i < out.Length(); i++ {
+		gain := a.gain(start + i)
+		for c := 0; c < out.Channels(); c++ {
+			idx := out.BufferIndex(c, i)
+			out.SetSample(idx, out.Sample(idx)*gain)
+		}
+	}
+}
+
+// gain returns interpolated gain at the position.
+func (a *automation) gain(pos int) float64 {
+	for a.next < len(a.points) && a.points[a.next].At <= pos {
+		a.next++
+	}
+	switch a.next {
+	case 0:
+		return a.points[0].Gain
+	case len(a.points):
+		return a.points[len(a.points)-1].Gain
+	}
+	p0, p1 := a.points[a.next-1], a.points[a.next]
+	return p0.Gain + (p1.Gain-p0.Gain)*float64(pos-p0.At)/float64(p1.At-p0.At)
+}
+
 // linkAfter searches for a first link, that ends after passed index.
 func (l *link) nextAfter(index int) *link {
 	for l != nil {
@@ -121,15 +395,75 @@ func (t *Track) endIndex() int {
 	return t.tail.at + t.tail.data.Length()
 }
 
+// Duration returns the length of the track in samples.
+func (t *Track) Duration() int {
+	if t.tail == nil {
+		return 0
+	}
+	return t.endIndex()
+}
+
+// Gaps returns silent regions of the track, including the one before the
+// first clip.
+func (t *Track) Gaps() []Gap {
+	var gaps []Gap
+	end := 0
+	for l := t.head; l != nil; l = l.next {
+		if l.at > end {
+			gaps = append(gaps, Gap{From: end, To: l.at})
+		}
+		end = l.End()
+	}
+	return gaps
+}
+
 // AddClip to the track. If clip has no asset or zero length, it
-// won't be added to the track. Overlapped clips are realigned.
+// won't be added to the track. Overlaps are resolved by the track
+// resolver or according to the track overlap policy.
 func (t *Track) AddClip(at int, data signal.Signal) {
-	t.once.Do(func() {
-		t.channels = data.Channels()
-	})
-	if t.channels != data.Channels() {
-		panic(fmt.Sprintf("unexpected number of channels: %d want: %d", data.Channels(), t.channels))
+	data = t.matchChannels(data)
+	t.place(Clip{At: at, Data: data}, t.overlapResolver())
+}
+
+// place replaces clips overlapped by the added one with clips returned
+// by the resolver. Returned clips are placed with TruncateResolver, so
+// the later ones are kept if they overlap.
+func (t *Track) place(added Clip, resolver OverlapResolver) {
+	var (
+		links      []*link
+		overlapped []Clip
+	)
+	for l := t.head.nextAfter(added.At); l != nil && l.at < added.End(); l = l.next {
+		links = append(links, l)
+		overlapped = append(overlapped, Clip{At: l.at, Data: l.data})
+	}
+	if len(overlapped) == 0 {
+		t.insert(added.At, added.Data)
+		return
+	}
+	for _, l := range links {
+		t.remove(l)
+	}
+	for _, c := range resolver.Resolve(added, overlapped) {
+		if c.Data.Length() > 0 {
+			t.place(c, TruncateResolver{})
+		}
+	}
+}
+
+func (t *Track) overlapResolver() OverlapResolver {
+	switch {
+	case t.Resolver != nil:
+		return t.Resolver
+	case t.Overlap == KeepExisting:
+		return KeepExistingResolver{}
 	}
+	return TruncateResolver{}
+}
+
+// insert links the clip into the track. Clip must not overlap existing
+// ones.
+func (t *Track) insert(at int, data signal.Signal) {
 	// create a new link.
 	l := &link{
 		at:   at,
@@ -170,55 +504,155 @@ func (t *Track) AddClip(at int, data signal.Signal) {
 	}
 	l.next = next
 	l.prev = prev
+}
 
-	// resolve overlaps in the track.
-	t.resolveOverlaps(l)
+// AddClipCopy adds a copy of the clip data to the track. Unlike AddClip,
+// the track doesn't alias the passed signal, so later changes of the
+// source, e.g. Asset.Normalize, don't affect the clip. The cost is an
+// extra buffer allocated per clip.
+func (t *Track) AddClipCopy(at int, data signal.Signal) {
+	t.AddClip(at, copySignal(data))
 }
 
-// resolveOverlaps resolves overlaps
-func (t *Track) resolveOverlaps(l *link) {
-	t.alignNextLink(l)
-	t.alignPrevLink(l)
+// InsertClip to the track in ripple mode. All clips after the insertion
+// point are shifted right by the length of inserted clip. If insertion
+// point is in the middle of the clip, it's split and its tail is shifted.
+func (t *Track) InsertClip(at int, data signal.Signal) {
+	data = t.matchChannels(data)
+	t.shiftFrom(at, data.Length())
+	t.AddClip(at, data)
 }
 
-func (t *Track) alignNextLink(l *link) {
-	next := l.next
-	if next == nil {
+// shiftFrom moves all samples of the track starting at index by offset.
+// The link that contains the index is split.
+func (t *Track) shiftFrom(at, offset int) {
+	for l := t.head; l != nil; l = l.next {
+		if l.at >= at {
+			l.at += offset
+			continue
+		}
+		if l.End() > at {
+			tail := &link{
+				at:   at + offset,
+				data: signal.Slice(l.data, at-l.at, l.data.Length()),
+				prev: l,
+				next: l.next,
+			}
+			if l.next != nil {
+				l.next.prev = tail
+			} else {
+				t.tail = tail
+			}
+			l.data = signal.Slice(l.data, 0, at-l.at)
+			l.next = tail
+			// tail is already shifted.
+			l = tail
+		}
+	}
+}
+
+// DeleteRange removes [from, to) range of samples from the track in
+// ripple mode. Clips that are fully covered by the range are removed,
+// partially covered clips are trimmed and all clips after the range are
+// shifted left to close the gap.
+func (t *Track) DeleteRange(from, to int) {
+	if from >= to {
 		return
 	}
-	overlap := l.End() - next.at
-	if overlap > 0 {
-		if next.data.Length() > overlap {
-			// shorten next
-			next.data = signal.Slice(next.data, overlap, next.data.Length())
-			next.at = next.at + overlap
-		} else {
-			// remove next
-			l.next = next.next
+	length := to - from
+	for l := t.head; l != nil; l = l.next {
+		switch {
+		case l.End() <= from:
+			// before the range.
+		case l.at >= to:
+			// after the range.
+			l.at -= length
+		case l.at >= from && l.End() <= to:
+			// covered by the range.
+			t.remove(l)
+		case l.at < from && l.End() > to:
+			// contains the range, split it.
+			tail := &link{
+				at:   from,
+				data: signal.Slice(l.data, to-l.at, l.data.Length()),
+				prev: l,
+				next: l.next,
+			}
 			if l.next != nil {
-				l.next.prev = l
+				l.next.prev = tail
 			} else {
-				t.tail = l
+				t.tail = tail
 			}
-			t.alignNextLink(l)
+			l.data = signal.Slice(l.data, 0, from-l.at)
+			l.next = tail
+			// tail is already shifted.
+			l = tail
+		case l.at < from:
+			// overlaps the range start.
+			l.data = signal.Slice(l.data, 0, from-l.at)
+		default:
+			// overlaps the range end.
+			l.data = signal.Slice(l.data, to-l.at, l.data.Length())
+			l.at = from
 		}
 	}
 }
 
-func (t *Track) alignPrevLink(l *link) {
-	prev := l.prev
-	if prev == nil {
-		return
+// remove unlinks the link from the track. Link keeps its next pointer, so
+// the track can be iterated while links are removed.
+func (t *Track) remove(l *link) {
+	if l.prev != nil {
+		l.prev.next = l.next
+	} else {
+		t.head = l.next
+	}
+	if l.next != nil {
+		l.next.prev = l.prev
+	} else {
+		t.tail = l.prev
 	}
-	overlap := prev.End() - l.at
-	if overlap > 0 {
-		prevLen := prev.data.Length()
-		prev.data = signal.Slice(prev.data, 0, prevLen-overlap)
-		// need to split previous clip
-		if overlap > l.data.Length() {
-			at := l.at + l.data.Length()
-			t.AddClip(at, signal.Slice(prev.data, prevLen-l.data.Length(), prevLen)) // -1 because slicing includes left index
+}
+
+// matchChannels returns the clip data with the number of channels of the
+// track. The first clip defines the number of channels. If data doesn't
+// match and channels adaptation is disabled, it panics.
+func (t *Track) matchChannels(data signal.Signal) signal.Signal {
+	t.once.Do(func() {
+		t.channels = data.Channels()
+	})
+	if t.channels == data.Channels() {
+		return data
+	}
+	if !t.AdaptChannels {
+		panic(fmt.Sprintf("unexpected number of channels: %d want: %d", data.Channels(), t.channels))
+	}
+	return adaptChannels(data, t.channels)
+}
+
+// adaptChannels copies the signal into floating buffer with provided
+// number of channels.
+func adaptChannels(data signal.Signal, channels int) signal.Floating {
+	in := signal.Allocator{
+		Channels: data.Channels(),
+		Length:   data.Length(),
+		Capacity: data.Length(),
+	}.Float64()
+	signal.AsFloating(data, in)
+	out := signal.Allocator{
+		Channels: channels,
+		Length:   data.Length(),
+		Capacity: data.Length(),
+	}.Float64()
+	for c := 0; c < channels; c++ {
+		for i := 0; i < data.Length(); i++ {
+			var sum float64
+			n := 0
+			for src := c % in.Channels(); src < in.Channels(); src += channels {
+				sum += in.Sample(in.BufferIndex(src, i))
+				n++
+			}
+			out.SetSample(out.BufferIndex(c, i), sum/float64(n))
 		}
-		// TODO: handle full overlap
 	}
+	return out
 }
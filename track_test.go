@@ -2,7 +2,10 @@ package audio_test
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
 	"pipelined.dev/audio"
 	"pipelined.dev/pipe"
@@ -115,6 +118,38 @@ func TestTrack(t *testing.T) {
 			expected: []float64{0, 21, 22, 23, 24, 25, 26, 27, 28},
 			msg:      "Overlap two completely",
 		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 5)},
+				{2, sample2.Slice(3, 6)},
+			},
+			expected: []float64{0, 0, 23, 24, 25},
+			msg:      "Overlap previous completely",
+		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 5)},
+				{2, sample2.Slice(3, 5)},
+			},
+			expected: []float64{0, 0, 23, 24},
+			msg:      "Overlap previous completely same length",
+		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 8)},
+				{2, sample2.Slice(3, 5)},
+			},
+			expected: []float64{0, 0, 23, 24, 15, 16, 17},
+			msg:      "Overlap previous beginning",
+		},
+		{
+			clips: []clip{
+				{0, sample1.Slice(0, 10)},
+				{2, sample2.Slice(2, 5)},
+			},
+			expected: []float64{10, 11, 22, 23, 24, 15, 16, 17, 18, 19},
+			msg:      "Overlap single in the middle uneven",
+		},
 	}
 
 	bufferSize := 2
@@ -140,3 +175,698 @@ func TestTrack(t *testing.T) {
 		assertEqual(t, test.msg, result, test.expected)
 	}
 }
+
+func TestTrackAutomation(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 4,
+		Length:   4,
+	}
+	sample := alloc.Float64()
+	signal.WriteFloat64([]float64{1, 1, 1, 1}, sample)
+
+	tests := []struct {
+		points   []audio.AutomationPoint
+		expected []float64
+		msg      string
+	}{
+		{
+			points:   nil,
+			expected: []float64{0, 1, 1, 0, 1, 1},
+			msg:      "No points",
+		},
+		{
+			points:   []audio.AutomationPoint{{At: 0, Gain: 0.5}},
+			expected: []float64{0, 0.5, 0.5, 0, 0.5, 0.5},
+			msg:      "Single point",
+		},
+		{
+			points: []audio.AutomationPoint{
+				{At: 5, Gain: 0},
+				{At: 1, Gain: 1},
+			},
+			expected: []float64{0, 1, 0.75, 0, 0.25, 0},
+			msg:      "Ramp across gap",
+		},
+	}
+
+	bufferSize := 4
+	for _, test := range tests {
+		track := audio.Track{}
+		track.AddClip(1, sample.Slice(0, 2))
+		track.AddClip(4, sample.Slice(0, 2))
+
+		sink := &mock.Sink{}
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: track.SourceWithAutomation(44100, 0, 0, test.points),
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestTrackGaps(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}.Float64()
+
+	tests := []struct {
+		clips    map[int]int
+		gaps     []audio.Gap
+		duration int
+		msg      string
+	}{
+		{
+			msg: "Empty",
+		},
+		{
+			clips:    map[int]int{0: 3, 3: 2},
+			duration: 5,
+			msg:      "No gaps",
+		},
+		{
+			clips:    map[int]int{2: 3, 7: 2, 9: 1},
+			gaps:     []audio.Gap{{From: 0, To: 2}, {From: 5, To: 7}},
+			duration: 10,
+			msg:      "Leading and middle gaps",
+		},
+	}
+
+	for _, test := range tests {
+		track := audio.Track{}
+		for at, length := range test.clips {
+			track.AddClip(at, sample.Slice(0, length))
+		}
+		assertEqual(t, test.msg+" gaps", track.Gaps(), test.gaps)
+		assertEqual(t, test.msg+" duration", track.Duration(), test.duration)
+	}
+}
+
+func TestTrackRender(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 1,
+		Capacity: 3,
+		Length:   3,
+	}.Float64()
+	signal.WriteFloat64([]float64{1, 2, 3}, sample)
+
+	track := audio.Track{}
+	track.AddClip(1, sample.Slice(0, 2))
+	track.AddClip(5, sample)
+	track.AddClip(6, sample.Slice(0, 1))
+
+	asset, err := track.Render(44100, 2)
+	assertNil(t, "error", err)
+	assertEqual(t, "sample rate", asset.SampleRate(), signal.Frequency(44100))
+
+	result := make([]float64, asset.Signal.Len())
+	signal.ReadFloat64(asset.Signal.(signal.Floating), result)
+	assertEqual(t, "result", result, []float64{0, 1, 2, 0, 0, 1, 1, 3})
+}
+
+func TestTrackInsertClip(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}
+	sample1 := alloc.Float64()
+	signal.WriteFloat64([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, sample1)
+	sample2 := alloc.Float64()
+	signal.WriteFloat64([]float64{20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, sample2)
+
+	type clip struct {
+		position int
+		data     signal.Floating
+	}
+	tests := []struct {
+		clips    []clip
+		insert   clip
+		expected []float64
+		msg      string
+	}{
+		{
+			clips: []clip{
+				{1, sample1.Slice(0, 2)},
+				{4, sample1.Slice(2, 4)},
+			},
+			insert:   clip{3, sample2.Slice(0, 2)},
+			expected: []float64{0, 10, 11, 20, 21, 0, 12, 13},
+			msg:      "Insert into gap",
+		},
+		{
+			clips: []clip{
+				{1, sample1.Slice(0, 2)},
+				{3, sample1.Slice(2, 4)},
+			},
+			insert:   clip{3, sample2.Slice(0, 1)},
+			expected: []float64{0, 10, 11, 20, 12, 13},
+			msg:      "Insert at clip start",
+		},
+		{
+			clips: []clip{
+				{1, sample1.Slice(0, 4)},
+				{6, sample1.Slice(4, 5)},
+			},
+			insert:   clip{3, sample2.Slice(0, 2)},
+			expected: []float64{0, 10, 11, 20, 21, 12, 13, 0, 14},
+			msg:      "Insert in the middle of clip",
+		},
+		{
+			clips: []clip{
+				{0, sample1.Slice(0, 2)},
+			},
+			insert:   clip{3, sample2.Slice(0, 2)},
+			expected: []float64{10, 11, 0, 20, 21},
+			msg:      "Insert after end",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		track := audio.Track{}
+		for _, clip := range test.clips {
+			track.AddClip(clip.position, clip.data)
+		}
+		track.InsertClip(test.insert.position, test.insert.data)
+
+		sink := &mock.Sink{}
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: track.Source(44100, 0, 0),
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestTrackDeleteRange(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}
+	sample1 := alloc.Float64()
+	signal.WriteFloat64([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, sample1)
+	sample2 := alloc.Float64()
+	signal.WriteFloat64([]float64{20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, sample2)
+
+	type clip struct {
+		position int
+		data     signal.Floating
+	}
+	tests := []struct {
+		clips    []clip
+		from, to int
+		expected []float64
+		msg      string
+	}{
+		{
+			clips: []clip{
+				{1, sample1.Slice(0, 2)},
+				{5, sample2.Slice(0, 2)},
+			},
+			from:     3,
+			to:       5,
+			expected: []float64{0, 10, 11, 20, 21},
+			msg:      "Delete gap",
+		},
+		{
+			clips: []clip{
+				{0, sample1.Slice(0, 6)},
+			},
+			from:     2,
+			to:       4,
+			expected: []float64{10, 11, 14, 15},
+			msg:      "Delete in the middle of clip",
+		},
+		{
+			clips: []clip{
+				{0, sample1.Slice(0, 3)},
+				{3, sample2.Slice(0, 2)},
+				{5, sample1.Slice(5, 8)},
+			},
+			from:     2,
+			to:       6,
+			expected: []float64{10, 11, 16, 17},
+			msg:      "Delete covering clip and overlapping others",
+		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(0, 2)},
+				{6, sample2.Slice(0, 2)},
+			},
+			from:     0,
+			to:       4,
+			expected: []float64{0, 0, 20, 21},
+			msg:      "Delete first clip",
+		},
+		{
+			clips: []clip{
+				{0, sample1.Slice(0, 2)},
+				{2, sample2.Slice(0, 2)},
+			},
+			from:     1,
+			to:       10,
+			expected: []float64{10},
+			msg:      "Delete tail",
+		},
+	}
+
+	bufferSize := 2
+	for _, test := range tests {
+		track := audio.Track{}
+		for _, clip := range test.clips {
+			track.AddClip(clip.position, clip.data)
+		}
+		track.DeleteRange(test.from, test.to)
+
+		sink := &mock.Sink{}
+		p, _ := pipe.New(bufferSize,
+			pipe.Line{
+				Source: track.Source(44100, 0, 0),
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestTrackEdgeSmoothing(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 2,
+		Capacity: 8,
+		Length:   8,
+	}.Float64()
+	for i := 0; i < sample.Len(); i++ {
+		sample.SetSample(i, 1)
+	}
+
+	track := audio.Track{EdgeSmoothing: 3}
+	track.AddClip(1, sample)
+	track.AddClip(9, sample.Slice(0, 4))
+
+	asset, err := track.Render(44100, 3)
+	assertNil(t, "error", err)
+
+	result := make([]float64, asset.Signal.Len())
+	signal.ReadFloat64(asset.Signal.(signal.Floating), result)
+	expected := []float64{
+		0, 0,
+		0, 0, 1.0 / 3, 1.0 / 3, 2.0 / 3, 2.0 / 3,
+		1, 1, 1, 1,
+		2.0 / 3, 2.0 / 3, 1.0 / 3, 1.0 / 3, 0, 0,
+		// clip shorter than both ramps.
+		0, 0, 1.0 / 3, 1.0 / 3, 1.0 / 3, 1.0 / 3, 0, 0,
+	}
+	assertEqual(t, "result", result, expected)
+}
+
+func TestTrackEdgeCurve(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 1,
+		Capacity: 4,
+		Length:   4,
+	}.Float64()
+	signal.WriteFloat64([]float64{1, 1, 1, 1}, sample)
+
+	track := audio.Track{EdgeSmoothing: 2, EdgeCurve: audio.FadeSCurve}
+	track.AddClip(0, sample)
+
+	result := signal.Allocator{
+		Channels: 1,
+		Capacity: 4,
+		Length:   4,
+	}.Float64()
+	track.ReadAt(result, 0)
+	for i, expected := range []float64{0, 0.5, 0.5, 0} {
+		assertEqual(t, "result", math.Abs(result.Sample(i)-expected) < 1e-12, true)
+	}
+}
+
+func TestTrackAddClipCopy(t *testing.T) {
+	asset := &audio.Asset{
+		Signal: signal.Allocator{
+			Channels: 1,
+			Capacity: 4,
+			Length:   4,
+		}.Float64(),
+	}
+	signal.WriteFloat64([]float64{0.1, 0.2, 0.3, 0.4}, asset.Signal.(signal.Floating))
+
+	track := audio.Track{}
+	track.AddClip(0, asset.Slice(0, 2).Signal)
+	track.AddClipCopy(2, asset.Slice(2, 4).Signal)
+	assertNil(t, "normalize error", asset.Normalize(0))
+
+	result, err := track.Render(44100, 2)
+	assertNil(t, "render error", err)
+	values := make([]float64, result.Signal.Len())
+	signal.ReadFloat64(result.Signal.(signal.Floating), values)
+	assertEqual(t, "result", values, []float64{0.25, 0.5, 0.3, 0.4})
+}
+
+func TestTrackSourceDuration(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}.Float64()
+	signal.WriteFloat64([]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, sample)
+	track := audio.Track{}
+	track.AddClip(0, sample)
+
+	source := func(start, end time.Duration) []float64 {
+		sink := &mock.Sink{}
+		p, _ := pipe.New(4,
+			pipe.Line{
+				// single sample lasts 100ms.
+				Source: track.SourceDuration(10, start, end),
+				Sink:   sink.Sink(),
+			},
+		)
+		_ = pipe.Wait(p.Start(context.Background()))
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		return result
+	}
+	assertEqual(t, "head", source(0, 250*time.Millisecond), []float64{0, 1, 2})
+	assertEqual(t, "tail", source(250*time.Millisecond, time.Second), []float64{3, 4, 5, 6, 7, 8, 9})
+	assertEqual(t, "exact", source(300*time.Millisecond, 500*time.Millisecond), []float64{3, 4})
+	assertEqual(t, "whole", source(0, 0), []float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+
+	// index of long duration overflows int64 nanoseconds times rate.
+	const rate, hours = 192000, 14
+	long := audio.Track{}
+	long.AddClip(hours*3600*rate, sample.Slice(0, 3))
+	longSource := func(start time.Duration) []float64 {
+		sink := &mock.Sink{}
+		p, err := pipe.New(4,
+			pipe.Line{
+				Source: long.SourceDuration(rate, start, 0),
+				Sink:   sink.Sink(),
+			},
+		)
+		assertNil(t, "error", err)
+		assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+		result := make([]float64, sink.Values.Len())
+		signal.ReadFloat64(sink.Values, result)
+		return result
+	}
+	assertEqual(t, "long", longSource(hours*time.Hour), []float64{0, 1, 2})
+	assertEqual(t, "long ceil", longSource(hours*time.Hour+time.Nanosecond), []float64{1, 2})
+}
+
+func TestTrackOverlapKeepExisting(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}
+	sample1 := alloc.Float64()
+	signal.WriteFloat64([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, sample1)
+	sample2 := alloc.Float64()
+	signal.WriteFloat64([]float64{20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, sample2)
+
+	type clip struct {
+		position int
+		data     signal.Floating
+	}
+	tests := []struct {
+		clips    []clip
+		expected []float64
+		msg      string
+	}{
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 6)},
+				{7, sample1.Slice(0, 1)},
+				{0, sample2.Slice(0, 9)},
+			},
+			expected: []float64{20, 21, 13, 14, 15, 25, 26, 10, 28},
+			msg:      "Fill gaps around existing",
+		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 6)},
+				{3, sample2.Slice(0, 1)},
+			},
+			expected: []float64{0, 0, 13, 14, 15},
+			msg:      "Covered by existing",
+		},
+		{
+			clips: []clip{
+				{2, sample1.Slice(3, 6)},
+				{4, sample2.Slice(0, 3)},
+			},
+			expected: []float64{0, 0, 13, 14, 15, 21, 22},
+			msg:      "Overlap existing end",
+		},
+	}
+
+	for _, test := range tests {
+		track := audio.Track{Overlap: audio.KeepExisting}
+		for _, clip := range test.clips {
+			track.AddClip(clip.position, clip.data)
+		}
+		asset, err := track.Render(44100, 2)
+		assertNil(t, test.msg+" error", err)
+		result := make([]float64, asset.Signal.Len())
+		signal.ReadFloat64(asset.Signal.(signal.Floating), result)
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestTrackReadAt(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}
+	sample := alloc.Float64()
+	signal.WriteFloat64([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, sample)
+
+	track := audio.Track{}
+	track.AddClip(2, sample.Slice(0, 3))
+	track.AddClip(7, sample.Slice(5, 8))
+	track.AddClip(8, sample.Slice(0, 1))
+
+	tests := []struct {
+		at       int
+		length   int
+		expected []float64
+		msg      string
+	}{
+		{0, 4, []float64{0, 0, 10, 11}, "Start"},
+		{3, 5, []float64{11, 12, 0, 0, 15}, "Gap"},
+		{7, 4, []float64{15, 10, 17}, "Overlap and end"},
+		{10, 2, []float64{}, "After end"},
+	}
+	for _, test := range tests {
+		out := signal.Allocator{
+			Channels: 1,
+			Capacity: test.length,
+			Length:   test.length,
+		}.Float64()
+		signal.WriteFloat64([]float64{-1, -1, -1, -1, -1}, out)
+		n := track.ReadAt(out, test.at)
+		result := make([]float64, n)
+		signal.ReadFloat64(out, result)
+		assertEqual(t, test.msg, result, test.expected)
+	}
+}
+
+func TestTrackAbuttingClips(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 3,
+		Length:   3,
+	}
+	clipA := alloc.Float64()
+	signal.WriteFloat64([]float64{1, 2, 3}, clipA)
+	clipB := alloc.Float64()
+	signal.WriteFloat64([]float64{4, 5, 6}, clipB)
+	track := audio.Track{}
+	track.AddClip(1, clipA)
+	track.AddClip(4, clipB)
+	expected := []float64{0, 1, 2, 3, 4, 5, 6}
+
+	for bufferSize := 1; bufferSize <= len(expected); bufferSize++ {
+		for start := 0; start < len(expected); start++ {
+			sink := mock.Sink{}
+			p, err := pipe.New(bufferSize, pipe.Line{
+				Source: track.Source(44100, start, 0),
+				Sink:   sink.Sink(),
+			})
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+			result := make([]float64, sink.Values.Len())
+			signal.ReadFloat64(sink.Values, result)
+			assertEqual(t, fmt.Sprintf("buffer %d start %d", bufferSize, start), result, expected[start:])
+		}
+	}
+}
+
+func TestTrackProgress(t *testing.T) {
+	sample := signal.Allocator{
+		Channels: 1,
+		Capacity: 3,
+		Length:   3,
+	}.Float64()
+
+	var progress [][2]int
+	track := audio.Track{
+		Progress: func(pos, total int) {
+			progress = append(progress, [2]int{pos, total})
+		},
+	}
+	track.AddClip(2, sample)
+	track.AddClip(6, sample)
+
+	_, err := track.Render(44100, 4)
+	assertNil(t, "error", err)
+	assertEqual(t, "render", progress, [][2]int{{4, 9}, {8, 9}, {9, 9}})
+
+	progress = nil
+	p, err := pipe.New(2, pipe.Line{
+		Source: track.Source(44100, 3, 7),
+		Sink:   (&mock.Sink{Discard: true}).Sink(),
+	})
+	assertNil(t, "error", err)
+	assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+	assertEqual(t, "range", progress, [][2]int{{5, 7}, {7, 7}})
+}
+
+func TestTrackAdaptChannels(t *testing.T) {
+	mono := signal.Allocator{
+		Channels: 1,
+		Capacity: 2,
+		Length:   2,
+	}.Float64()
+	signal.WriteFloat64([]float64{0.5, 1}, mono)
+	stereo := signal.Allocator{
+		Channels: 2,
+		Capacity: 2,
+		Length:   2,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{{0.1, 0.2}, {0.3, 0.4}}, stereo)
+	ints := signal.Allocator{
+		Channels: 2,
+		Capacity: 1,
+		Length:   1,
+	}.Int64(signal.BitDepth8)
+	signal.WriteStripedInt64([][]int64{{127}, {-128}}, ints)
+
+	read := func(track *audio.Track) []float64 {
+		asset, err := track.Render(44100, 2)
+		assertNil(t, "error", err)
+		result := make([]float64, asset.Signal.Len())
+		signal.ReadFloat64(asset.Signal.(signal.Floating), result)
+		return result
+	}
+
+	upmix := &audio.Track{AdaptChannels: true}
+	upmix.AddClip(0, stereo)
+	upmix.AddClip(2, mono)
+	assertEqual(t, "upmix", read(upmix), []float64{0.1, 0.3, 0.2, 0.4, 0.5, 0.5, 1, 1})
+
+	downmix := &audio.Track{AdaptChannels: true}
+	downmix.AddClip(0, mono)
+	downmix.InsertClip(1, stereo)
+	downmix.AddClip(4, ints)
+	assertEqual(t, "downmix", read(downmix), []float64{0.5, 0.2, 0.30000000000000004, 1, 0})
+
+	defer func() {
+		assertEqual(t, "strict panic", recover() != nil, true)
+	}()
+	strict := &audio.Track{}
+	strict.AddClip(0, stereo)
+	strict.AddClip(2, mono)
+}
+
+// rejectResolver keeps existing clips and drops added clip if it
+// overlaps any of them.
+type rejectResolver struct {
+	overlapped [][]audio.Clip
+}
+
+func (r *rejectResolver) Resolve(added audio.Clip, overlapped []audio.Clip) []audio.Clip {
+	r.overlapped = append(r.overlapped, overlapped)
+	return overlapped
+}
+
+// layerResolver returns overlapping clips, existing ones after added.
+type layerResolver struct{}
+
+func (layerResolver) Resolve(added audio.Clip, overlapped []audio.Clip) []audio.Clip {
+	return append([]audio.Clip{added}, overlapped...)
+}
+
+func TestTrackResolver(t *testing.T) {
+	alloc := signal.Allocator{
+		Channels: 1,
+		Capacity: 10,
+		Length:   10,
+	}
+	sample1 := alloc.Float64()
+	signal.WriteFloat64([]float64{10, 11, 12, 13, 14, 15, 16, 17, 18, 19}, sample1)
+	sample2 := alloc.Float64()
+	signal.WriteFloat64([]float64{20, 21, 22, 23, 24, 25, 26, 27, 28, 29}, sample2)
+
+	render := func(track *audio.Track) []float64 {
+		asset, err := track.Render(44100, 3)
+		assertNil(t, "error", err)
+		result := make([]float64, asset.Signal.Len())
+		signal.ReadFloat64(asset.Signal.(signal.Floating), result)
+		return result
+	}
+
+	resolver := &rejectResolver{}
+	track := &audio.Track{Resolver: resolver}
+	track.AddClip(1, sample1.Slice(0, 2))
+	track.AddClip(4, sample1.Slice(2, 4))
+	track.AddClip(2, sample2.Slice(0, 3))
+	track.AddClip(7, sample2.Slice(5, 6))
+	assertEqual(t, "custom", render(track), []float64{0, 10, 11, 0, 12, 13, 0, 25})
+	assertEqual(t, "custom calls", len(resolver.overlapped), 1)
+	assertEqual(t, "custom overlapped", len(resolver.overlapped[0]), 2)
+	assertEqual(t, "custom overlapped positions", []int{resolver.overlapped[0][0].At, resolver.overlapped[0][1].At}, []int{1, 4})
+
+	truncate := &audio.Track{Resolver: audio.TruncateResolver{}}
+	truncate.AddClip(0, sample1.Slice(0, 8))
+	truncate.AddClip(2, sample2.Slice(2, 5))
+	assertEqual(t, "truncate", render(truncate), []float64{10, 11, 22, 23, 24, 15, 16, 17})
+
+	// resolver overrides the policy.
+	override := &audio.Track{Resolver: audio.TruncateResolver{}, Overlap: audio.KeepExisting}
+	override.AddClip(2, sample1.Slice(0, 2))
+	override.AddClip(1, sample2.Slice(0, 4))
+	assertEqual(t, "override", render(override), []float64{0, 20, 21, 22, 23})
+
+	// overlapping clips are truncated, so the later ones are kept.
+	layer := &audio.Track{Resolver: layerResolver{}}
+	layer.AddClip(2, sample1.Slice(0, 2))
+	layer.AddClip(6, sample1.Slice(2, 3))
+	layer.AddClip(1, sample2.Slice(0, 8))
+	assertEqual(t, "layer", render(layer), []float64{0, 20, 10, 11, 23, 24, 12, 26, 27})
+}
@@ -0,0 +1,31 @@
+package audio
+
+import (
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+// StereoWidth provides mid/side stereo width processor. Side component
+// of the signal is scaled by width: 0 collapses the signal to mono, 1
+// leaves it unchanged and values above 1 widen it.
+func StereoWidth(width float64) pipe.ProcessorAllocatorFunc {
+	return func(mut mutable.Context, bufferSize int, props pipe.SignalProperties) (pipe.Processor, error) {
+		if props.Channels != 2 {
+			return pipe.Processor{}, ErrNotStereo
+		}
+		return pipe.Processor{
+			SignalProperties: props,
+			ProcessFunc: func(in, out signal.Floating) (int, error) {
+				for i := 0; i < in.Length(); i++ {
+					l, r := in.BufferIndex(0, i), in.BufferIndex(1, i)
+					mid := (in.Sample(l) + in.Sample(r)) / 2
+					side := (in.Sample(l) - in.Sample(r)) / 2 * width
+					out.SetSample(l, mid+side)
+					out.SetSample(r, mid-side)
+				}
+				return in.Length(), nil
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,49 @@
+package audio_test
+
+import (
+	"context"
+	"testing"
+
+	"pipelined.dev/audio"
+	"pipelined.dev/pipe"
+	"pipelined.dev/pipe/mock"
+	"pipelined.dev/pipe/mutable"
+	"pipelined.dev/signal"
+)
+
+func TestStereoWidth(t *testing.T) {
+	floats := signal.Allocator{
+		Channels: 2,
+		Length:   3,
+		Capacity: 3,
+	}.Float64()
+	signal.WriteStripedFloat64([][]float64{{1, 0.5, -0.25}, {0, 0.25, 0.75}}, floats)
+
+	width := func(width float64, expected [][]float64) func(*testing.T) {
+		return func(t *testing.T) {
+			t.Helper()
+			sink := &mock.Sink{}
+			p, err := pipe.New(2,
+				pipe.Line{
+					Source:     audio.Source(44100, floats),
+					Processors: pipe.Processors(audio.StereoWidth(width)),
+					Sink:       sink.Sink(),
+				},
+			)
+			assertNil(t, "error", err)
+			assertNil(t, "error", pipe.Wait(p.Start(context.Background())))
+
+			result := [][]float64{make([]float64, 3), make([]float64, 3)}
+			signal.ReadStripedFloat64(sink.Values, result)
+			assertEqual(t, "result", result, expected)
+		}
+	}
+	t.Run("mono", width(0, [][]float64{{0.5, 0.375, 0.25}, {0.5, 0.375, 0.25}}))
+	t.Run("unchanged", width(1, [][]float64{{1, 0.5, -0.25}, {0, 0.25, 0.75}}))
+	t.Run("wide", width(2, [][]float64{{1.5, 0.625, -0.75}, {-0.5, 0.125, 1.25}}))
+}
+
+func TestStereoWidthNotStereo(t *testing.T) {
+	_, err := audio.StereoWidth(1)(mutable.Mutable(), 2, pipe.SignalProperties{Channels: 1})
+	assertEqual(t, "error", err, audio.ErrNotStereo)
+}